@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+var baselineOutputPath string
+
+// baselineCmd represents the baseline command
+var baselineCmd = &cobra.Command{
+	Use:   "baseline [path to application export zip]...",
+	Short: "baseline records the current findings of an Endeca Application as accepted",
+	Long: `baseline validates an Endeca Application and writes all findings to a baseline
+file. Findings in the baseline are matched by a fingerprint of the rule, file
+and subject of the finding, so they stay accepted when lines move. Run it again
+to update the baseline after fixing findings.
+For example:
+    cartridgemapp baseline /full/path/to/endeca/exported/Application.zip
+    cartridgemapp validate /full/path/to/endeca/exported/Application.zip --baseline cartridgemapper-baseline.json
+`,
+	Example: "cartridgemapp baseline /full/path/to/endeca/exported/Application.zip --output legacy-baseline.json",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var endecaAppPaths []string = args
+		start := time.Now()
+		app, exitCode := writeEndecaAppBaseline(endecaAppPaths)
+		finishRun(start, app, exitCode)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(baselineCmd)
+	baselineCmd.Flags().StringVarP(&baselineOutputPath, "output", "o", "cartridgemapper-baseline.json", "Baseline file to write")
+	addLinkFlags(baselineCmd)
+	addIgnoreFlags(baselineCmd)
+	addAppRootFlag(baselineCmd)
+}
+
+// writeEndecaAppBaseline validates the application and writes all findings to the baseline file
+func writeEndecaAppBaseline(endecaAppPaths []string) (endeca.Application, int) {
+	app, exitCode := mapApplication(endecaAppPaths)
+	if exitCode == exitInput {
+		return app, exitCode
+	}
+	findings := endeca.Validate(extractDirectory, app, DisableColor, Debug)
+	if err := endeca.WriteBaseline(baselineOutputPath, endeca.NewBaseline(findings)); err != nil {
+		utils.DisplayError("Couldn't write baseline file "+baselineOutputPath, err, DisableColor)
+		return app, exitOutput
+	}
+	utils.DisplayInfo("Created baseline with "+strconv.Itoa(len(findings))+" findings at "+baselineOutputPath, DisableColor)
+	return app, exitCode
+}
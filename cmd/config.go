@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// workers is the number of templates scanned at the same time
+var workers int
+
+// forceConfig allows config init to overwrite an existing config file
+var forceConfig bool
+
+// severitiesKey is the config key of the finding severity overrides, the only option that
+// isn't a flag as well
+const severitiesKey = "severities"
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "config creates and checks the cartridgemapper config file",
+	Long: `Every flag can be set in a .cartridgemapper.yaml config file, by the flag name,
+or as a CARTRIDGEMAPPER_<FLAG> environment variable with dashes replaced by
+underscores, e.g. CARTRIDGEMAPPER_LOG_LEVEL=debug. Flags take precedence over
+environment variables, which take precedence over the config file.
+
+The config file is looked for in the current directory and its parents, then in
+the home directory, unless --config is given. The severity of kinds of findings
+can only be set in the config file, under severities.
+`,
+}
+
+// configInitCmd represents the config init command
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "init writes a .cartridgemapper.yaml with every option and its default",
+	Long: `init writes a .cartridgemapper.yaml config file in the current directory, or to
+the --config path, listing every option with its default commented out.
+For example:
+    cartridgemapp config init
+    cartridgemapp config init --config ci/cartridgemapper.yaml --force
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !writeConfigFile() {
+			os.Exit(exitOutput)
+		}
+	},
+}
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "validate checks the config file for unknown options and invalid values",
+	Long: `validate reads the config file that would be used, or the --config path, and
+reports unknown options and invalid values. It exits with status 2 when the
+config file has problems or can't be found.
+For example:
+    cartridgemapp config validate
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !validateConfigFile() {
+			os.Exit(exitInput)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configInitCmd.Flags().BoolVarP(&forceConfig, "force", "", false, "Overwrite an existing config file")
+}
+
+// applyConfig sets the flags of a command that weren't given on the command line from the
+// environment or config file, and applies the options that aren't flags
+func applyConfig(cmd *cobra.Command) error {
+	var applyError error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if applyError != nil || f.Changed || f.Name == "config" || f.Name == "help" || !viper.IsSet(f.Name) {
+			return
+		}
+		for _, value := range configValues(viper.Get(f.Name)) {
+			if err := f.Value.Set(value); err != nil {
+				applyError = fmt.Errorf("invalid value %q for %s in config: %v", value, f.Name, err)
+				return
+			}
+		}
+	})
+	if applyError != nil {
+		return applyError
+	}
+	if workers < 1 {
+		return errors.New("workers has to be at least 1")
+	}
+	endeca.Workers = workers
+	endeca.LocaleFallback = localeFallback
+	return endeca.SetSeverities(viper.GetStringMapString(severitiesKey))
+}
+
+// configValues returns the config value of a flag as the values to set it to. List values
+// set array flags to every item of the list.
+func configValues(value interface{}) []string {
+	if list, ok := value.([]interface{}); ok {
+		var values []string
+		for _, item := range list {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	}
+	if list, ok := value.([]string); ok {
+		return list
+	}
+	return []string{fmt.Sprint(value)}
+}
+
+// configFlags returns every flag of every command by name, the options of the config file
+func configFlags() map[string]*pflag.Flag {
+	flags := make(map[string]*pflag.Flag)
+	var visit func(command *cobra.Command)
+	visit = func(command *cobra.Command) {
+		add := func(f *pflag.Flag) {
+			if f.Name != "config" && f.Name != "help" && f.Name != "force" {
+				flags[f.Name] = f
+			}
+		}
+		command.PersistentFlags().VisitAll(add)
+		command.Flags().VisitAll(add)
+		for _, child := range command.Commands() {
+			visit(child)
+		}
+	}
+	visit(rootCmd)
+	return flags
+}
+
+// configFilePath returns where config init writes the config file
+func configFilePath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	return ".cartridgemapper.yaml"
+}
+
+// writeConfigFile writes a config file with every option commented out at its default
+func writeConfigFile() bool {
+	path := configFilePath()
+	if _, err := os.Stat(path); err == nil && !forceConfig {
+		utils.DisplayError("Config file "+path+" already exists, use --force to overwrite it.", nil, DisableColor)
+		return false
+	}
+
+	flags := configFlags()
+	var names []string
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("# cartridgemapper config. Uncomment an option to change it, every option can also be\n")
+	b.WriteString("# given as a flag or a CARTRIDGEMAPPER_<OPTION> environment variable, e.g.\n")
+	b.WriteString("# CARTRIDGEMAPPER_LOG_LEVEL=debug.\n")
+	for _, name := range names {
+		f := flags[name]
+		b.WriteString("\n# " + f.Usage + "\n")
+		b.WriteString("#" + name + ": " + configDefault(f) + "\n")
+	}
+	b.WriteString("\n# Severity of kinds of findings: error, warning, note or none to turn them off\n")
+	b.WriteString("#" + severitiesKey + ":\n")
+	for _, rule := range endeca.FindingRules {
+		b.WriteString("#  " + rule.ID + ": " + rule.Severity + "\n")
+	}
+
+	if err := ioutil.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		utils.DisplayError("Couldn't write config file "+path, err, DisableColor)
+		return false
+	}
+	utils.DisplayInfo("Created config file at "+path, DisableColor)
+	return true
+}
+
+// configDefault formats the default of a flag as a YAML value
+func configDefault(f *pflag.Flag) string {
+	switch f.Value.Type() {
+	case "bool", "int":
+		return f.DefValue
+	case "stringArray", "stringSlice":
+		var values []string
+		for _, value := range strings.Split(strings.Trim(f.DefValue, "[]"), ",") {
+			if value != "" {
+				values = append(values, strconv.Quote(value))
+			}
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	default:
+		return strconv.Quote(f.DefValue)
+	}
+}
+
+// validateConfigFile reports the problems of the config file, returning false when it has any
+func validateConfigFile() bool {
+	if err := initConfig(); err != nil {
+		utils.DisplayError("Config file is invalid.", err, DisableColor)
+		return false
+	}
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		utils.DisplayError("No config file found, use config init to create one.", nil, DisableColor)
+		return false
+	}
+
+	problems := configProblems(configFlags())
+	for _, problem := range problems {
+		utils.WithFields(utils.Fields{"file": path}).Error(problem, nil)
+	}
+	if len(problems) > 0 {
+		utils.DisplayError("Config file "+path+" has "+strconv.Itoa(len(problems))+" problems.", nil, DisableColor)
+		return false
+	}
+	utils.DisplayInfo("Config file "+path+" is valid.", DisableColor)
+	return true
+}
+
+// configProblems checks the options of the config file against the flags they set
+func configProblems(flags map[string]*pflag.Flag) []string {
+	var problems []string
+	lowerFlags := make(map[string]*pflag.Flag)
+	for name, f := range flags {
+		lowerFlags[strings.ToLower(name)] = f
+	}
+
+	checked := make(map[string]bool)
+	for _, key := range viper.AllKeys() {
+		option := strings.SplitN(key, ".", 2)[0]
+		if checked[option] {
+			continue
+		}
+		checked[option] = true
+		if option == severitiesKey {
+			if err := checkSeverities(viper.GetStringMapString(severitiesKey)); err != nil {
+				problems = append(problems, err.Error())
+			}
+			continue
+		}
+		f, ok := lowerFlags[option]
+		if !ok {
+			problems = append(problems, "unknown option "+option)
+			continue
+		}
+		if err := checkConfigValue(f, viper.Get(option)); err != nil {
+			problems = append(problems, "invalid value for "+f.Name+": "+err.Error())
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// checkSeverities checks the severity overrides without applying them
+func checkSeverities(severities map[string]string) error {
+	saved := make([]endeca.FindingRule, len(endeca.FindingRules))
+	copy(saved, endeca.FindingRules)
+	defer copy(endeca.FindingRules, saved)
+	return endeca.SetSeverities(severities)
+}
+
+// checkConfigValue checks a config value has the type of its flag and, for options with a
+// fixed set of values, is one of them
+func checkConfigValue(f *pflag.Flag, value interface{}) error {
+	values := configValues(value)
+	if f.Value.Type() != "stringArray" && f.Value.Type() != "stringSlice" && len(values) != 1 {
+		return errors.New("expected a single value")
+	}
+	for _, value := range values {
+		var err error
+		switch f.Value.Type() {
+		case "bool":
+			_, err = strconv.ParseBool(value)
+		case "int":
+			var number int
+			number, err = strconv.Atoi(value)
+			if err == nil && f.Name == "workers" && number < 1 {
+				err = errors.New("has to be at least 1")
+			}
+		}
+		if err != nil {
+			return err
+		}
+		switch f.Name {
+		case "format":
+			if !isOutputFormat(value) {
+				return errors.New("unknown format " + value + ", use one of " + strings.Join(outputFormats, ", "))
+			}
+		case "log-level":
+			_, err = utils.ParseLevel(value)
+		case "log-format":
+			if value != utils.LogFormatText && value != utils.LogFormatJSON {
+				err = errors.New("unknown log format " + value)
+			}
+		case "progress":
+			switch value {
+			case utils.ProgressAuto, utils.ProgressBar, utils.ProgressJSON, utils.ProgressNone:
+			default:
+				err = errors.New("unknown progress mode " + value)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestCheckConfigValue(t *testing.T) {
+	flags := configFlags()
+	valid := map[string]interface{}{
+		"format":           "markdown",
+		"workers":          4,
+		"debug":            "true",
+		"renderer-pattern": []interface{}{`"(\w+)": Cartridge`, `case '(\w+)'`},
+	}
+	for name, value := range valid {
+		if err := checkConfigValue(flags[name], value); err != nil {
+			t.Errorf("checkConfigValue(%s, %v) failed: %v", name, value, err)
+		}
+	}
+
+	invalid := map[string]interface{}{
+		"format":    "pdf",
+		"workers":   0,
+		"debug":     "sometimes",
+		"log-level": "loud",
+		"address":   []interface{}{"a", "b"},
+	}
+	for name, value := range invalid {
+		if err := checkConfigValue(flags[name], value); err == nil {
+			t.Errorf("checkConfigValue(%s, %v) didn't fail", name, value)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// Exit codes of the commands. When a run has several problems the highest code is used,
+// so an output error isn't hidden by a parse error that happened before it.
+const (
+	// exitOK is a run without errors
+	exitOK = 0
+	// exitValidation is a run with (new) validation findings, or leaving changes to be made by hand
+	exitValidation = 1
+	// exitInput is a run that couldn't read its input: a bad flag, zip, baseline or config
+	exitInput = 2
+	// exitParse is a run where part of the application couldn't be parsed or no cartridges were found
+	exitParse = 3
+	// exitOutput is a run that couldn't write its output
+	exitOutput = 4
+)
+
+// maxExitCode returns the more severe of two exit codes
+func maxExitCode(a int, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
+
+// finishRun displays the summary of a run and exits with its exit code
+func finishRun(start time.Time, app endeca.Application, exitCode int) {
+	warnings, errors := utils.LogCounts()
+	utils.DisplayInfo("Summary: "+strconv.Itoa(len(app.Cartridges))+" cartridges, "+
+		strconv.Itoa(len(app.Sites))+" sites, "+
+		strconv.Itoa(len(app.Pages))+" pages, "+
+		strconv.Itoa(len(app.Rules))+" rules, "+
+		strconv.Itoa(warnings)+" warnings, "+
+		strconv.Itoa(errors)+" errors in "+
+		time.Since(start).Round(time.Millisecond).String()+" (exit code "+strconv.Itoa(exitCode)+")", DisableColor)
+	utils.CloseLogging()
+	if exitCode != exitOK {
+		os.Exit(exitCode)
+	}
+}
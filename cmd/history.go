@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/templates"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+var historyDir string
+var snapshotLabel string
+var historyApp string
+var trendsOutputPath string
+var gitRepo string
+var gitPath string
+var gitRevision string
+var lifecycleOutputPath string
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "history lists the snapshots of mapped Endeca Applications and charts their trends",
+	Long: `history lists the snapshots saved by mapEndecaApp --history-dir, oldest first,
+with their time, label, application and number of cartridges. With
+--trends-output it also writes an HTML page charting, per application, the
+number of cartridges and cartridges in use across the snapshots and the pages
+using every cartridge over time.
+For example:
+    cartridgemapp mapEndecaApp Application.zip --history-dir .cartridge-history --label release-12
+    cartridgemapp history --history-dir .cartridge-history
+    cartridgemapp history --history-dir .cartridge-history --app Application --trends-output trends.html
+
+With --git-repo it walks the commits of a local git repository the extracted
+export is committed to instead, oldest first. Every commit changing --git-path
+is mapped and for every cartridge the commit introducing its template, the
+first and last commit with a page using it and the commit removing its
+template are reported. Only the local repository is read, the git command has
+to be installed.
+    cartridgemapp history --git-repo ~/endeca-exports --git-path Discover --lifecycle-output lifecycles.json
+`,
+	Example: "cartridgemapp history --history-dir .cartridge-history --trends-output trends.html",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		start := time.Now()
+		if gitRepo != "" {
+			app, exitCode := gitHistory()
+			finishRun(start, app, exitCode)
+			return
+		}
+		exitCode := listHistory()
+		finishRun(start, endeca.Application{}, exitCode)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().StringVarP(&historyDir, "history-dir", "", "", "Directory of the snapshots")
+	historyCmd.Flags().StringVarP(&historyApp, "app", "", "", "Only the snapshots of this application")
+	historyCmd.Flags().StringVarP(&trendsOutputPath, "trends-output", "", "", "HTML file to write the usage trend charts to")
+	historyCmd.Flags().StringVarP(&gitRepo, "git-repo", "", "", "Local git repository to walk the commits of instead of the history directory")
+	historyCmd.Flags().StringVarP(&gitPath, "git-path", "", "", "Path of the extracted export in the git repository, the whole repository by default")
+	historyCmd.Flags().StringVarP(&gitRevision, "git-rev", "", "HEAD", "Revision to walk the commits up to")
+	historyCmd.Flags().StringVarP(&lifecycleOutputPath, "lifecycle-output", "", "", "JSON file to write the cartridge lifecycles of the git history to")
+	addIgnoreFlags(historyCmd)
+	addAppRootFlag(historyCmd)
+}
+
+// addHistoryFlags adds the flags for saving a snapshot of every mapped application to a command
+func addHistoryFlags(command *cobra.Command) {
+	command.Flags().StringVarP(&historyDir, "history-dir", "", "", "Directory to save a snapshot of every mapped application in")
+	command.Flags().StringVarP(&snapshotLabel, "label", "", "", "Label of the snapshots, e.g. a release or commit")
+}
+
+// saveSnapshot saves a snapshot of the application in the history directory, when one is
+// given. It returns false when the snapshot couldn't be saved.
+func saveSnapshot(app endeca.Application, name string) bool {
+	if historyDir == "" {
+		return true
+	}
+	snapshot, err := endeca.NewSnapshot(app, name, snapshotLabel, time.Now())
+	var path string
+	if err == nil {
+		path, err = endeca.SaveSnapshot(historyDir, snapshot)
+	}
+	if err != nil {
+		utils.DisplayError("Couldn't save a snapshot of "+name+" in "+historyDir, err, DisableColor)
+		return false
+	}
+	utils.DisplayInfo("Saved snapshot "+path, DisableColor)
+	return true
+}
+
+// listHistory displays the snapshots of the history directory and writes the trend charts
+func listHistory() int {
+	if historyDir == "" {
+		utils.DisplayError("No history directory, set one with --history-dir.", nil, DisableColor)
+		return exitInput
+	}
+	snapshots, err := endeca.ReadSnapshots(historyDir, false)
+	if err != nil {
+		utils.DisplayError("Couldn't read the snapshots in "+historyDir, err, DisableColor)
+		return exitInput
+	}
+	if historyApp != "" {
+		var appSnapshots []endeca.Snapshot
+		for _, snapshot := range snapshots {
+			if snapshot.App == historyApp {
+				appSnapshots = append(appSnapshots, snapshot)
+			}
+		}
+		snapshots = appSnapshots
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tLABEL\tAPPLICATION\tCARTRIDGES")
+	for _, snapshot := range snapshots {
+		fmt.Fprintln(w, snapshot.ID+"\t"+snapshot.Time.Local().Format("2006-01-02 15:04:05")+"\t"+snapshot.Label+"\t"+
+			snapshot.App+"\t"+strconv.Itoa(len(snapshot.Usage)))
+	}
+	w.Flush()
+	utils.DisplayInfo("Found "+strconv.Itoa(len(snapshots))+" snapshots in "+historyDir, DisableColor)
+
+	if trendsOutputPath != "" {
+		if err := templates.HistoryOutputHTML(snapshots, trendsOutputPath, DisableColor, Debug); err != nil {
+			return exitOutput
+		}
+	}
+	return exitOK
+}
+
+// gitHistory maps the application at every commit of the git repository changing the git
+// path and displays when every cartridge was introduced, first and last used and removed.
+// Commits without the application count as removing all cartridges.
+func gitHistory() (endeca.Application, int) {
+	var app endeca.Application
+	commits, err := utils.GitCommits(gitRepo, gitRevision, gitPath)
+	if err != nil {
+		utils.DisplayError("Couldn't read the commits of "+gitRepo, err, DisableColor)
+		return app, exitInput
+	}
+	if len(commits) == 0 {
+		utils.DisplayError("No commits change "+gitPath+" in "+gitRepo+".", nil, DisableColor)
+		return app, exitInput
+	}
+	tempDirectory, err := ioutil.TempDir("", "cartridgemapper-git")
+	if err != nil {
+		utils.DisplayError("Couldn't create a directory for the commits.", err, DisableColor)
+		return app, exitInput
+	}
+	defer removeDirectory(tempDirectory)
+
+	lifecycles := endeca.NewLifecycles()
+	exitCode := exitOK
+	for index, commit := range commits {
+		utils.DisplayInfo("Mapping commit "+commit.ShortHash()+" ("+strconv.Itoa(index+1)+" of "+
+			strconv.Itoa(len(commits))+"): "+commit.Subject, DisableColor)
+		archive := filepath.Join(tempDirectory, commit.Hash+".zip")
+		var sources []appSource
+		err := utils.GitArchive(gitRepo, commit.Hash, gitPath, archive)
+		if err == nil {
+			sources, err = applicationSources([]string{archive}, true)
+		}
+		if err != nil {
+			utils.DisplayWarning("No application at commit "+commit.ShortHash()+": "+err.Error(), DisableColor)
+			app = endeca.Application{}
+			lifecycles.Add(commit, app)
+			continue
+		}
+		var commitExitCode int
+		app, commitExitCode = mapSource(sources[0])
+		if commitExitCode == exitInput {
+			return app, commitExitCode
+		}
+		exitCode = maxExitCode(exitCode, commitExitCode)
+		lifecycles.Add(commit, app)
+		os.Remove(archive)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CARTRIDGE\tINTRODUCED\tFIRST USED\tLAST USED\tREMOVED")
+	for _, lifecycle := range lifecycles.List() {
+		fmt.Fprintln(w, lifecycle.ID+"\t"+commitName(lifecycle.Introduced)+"\t"+commitName(lifecycle.FirstUsed)+"\t"+
+			commitName(lifecycle.LastUsed)+"\t"+commitName(lifecycle.Removed))
+	}
+	w.Flush()
+
+	if lifecycleOutputPath != "" {
+		b, err := json.MarshalIndent(lifecycles.List(), "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(lifecycleOutputPath, append(b, '\n'), 0644)
+		}
+		if err != nil {
+			utils.DisplayError("Couldn't write the cartridge lifecycles to "+lifecycleOutputPath, err, DisableColor)
+			return app, exitOutput
+		}
+		utils.DisplayInfo("Created cartridge lifecycle file at "+lifecycleOutputPath, DisableColor)
+	}
+	return app, exitCode
+}
+
+// commitName shows a commit as its short hash and date, - for none
+func commitName(commit *utils.Commit) string {
+	if commit == nil {
+		return "-"
+	}
+	return commit.ShortHash() + " " + commit.Time.Local().Format("2006-01-02")
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+var impactOutputPath string
+
+// impactCmd represents the impact command
+var impactCmd = &cobra.Command{
+	Use:   "impact [cartridge ID] [path to application export zip]...",
+	Short: "impact shows everything in an Endeca Application that reaches a cartridge",
+	Long: `impact shows the blast radius of changing a cartridge: every site, page,
+content rule, collection slot and parent cartridge that reaches it, with the
+content.xml of each reference. Pages reach a cartridge by placing it or by
+referencing a content rule holding it.
+For example:
+    cartridgemapp impact HeroBanner /full/path/to/endeca/exported/Application.zip
+    cartridgemapp impact HeroBanner /full/path/to/endeca/exported/Application.zip --impact-output impact.json
+`,
+	Example: "cartridgemapp impact HeroBanner /full/path/to/endeca/exported/Application.zip --impact-output impact.json",
+	Args:    cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		start := time.Now()
+		app, exitCode := showImpact(args[0], args[1:])
+		finishRun(start, app, exitCode)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(impactCmd)
+	impactCmd.Flags().StringVarP(&impactOutputPath, "impact-output", "", "", "JSON file to write the impact to")
+	addIgnoreFlags(impactCmd)
+	addAppRootFlag(impactCmd)
+}
+
+// showImpact maps the application, displays everything reaching the cartridge and writes it
+// as JSON when asked to. It returns exitInput when the application has neither a template
+// for the cartridge nor references to it.
+func showImpact(cartridgeID string, endecaAppPaths []string) (endeca.Application, int) {
+	app, exitCode := mapApplication(endecaAppPaths)
+	if exitCode == exitInput {
+		return app, exitCode
+	}
+	impact := endeca.MapImpact(extractDirectory, app, cartridgeID, DisableColor, Debug)
+	if !impact.Defined && len(impact.References) == 0 {
+		utils.DisplayError("No template or reference for cartridge "+cartridgeID+" in the application.", nil, DisableColor)
+		return app, exitInput
+	}
+	if !impact.Defined {
+		utils.DisplayWarning("The application references cartridge "+cartridgeID+" but has no template for it.", DisableColor)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Sites:\t"+listOrNone(impact.Sites))
+	fmt.Fprintln(w, "Pages:\t"+listOrNone(impact.Pages))
+	fmt.Fprintln(w, "Content rules:\t"+listOrNone(impact.Rules))
+	fmt.Fprintln(w, "Slots:\t"+listOrNone(impact.Slots))
+	fmt.Fprintln(w, "Parent cartridges:\t"+listOrNone(impact.Parents))
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "KIND\tFILE\tREACHED BY\tPLACED IN")
+	for _, reference := range impact.References {
+		reachedBy := reference.Rule
+		if reference.Page != "" {
+			reachedBy = reference.Site + "/" + reference.Page
+			if reference.Rule != "" {
+				reachedBy += " via " + reference.Rule
+			}
+		}
+		placedIn := "-"
+		if reference.Parent != "" {
+			placedIn = reference.Parent + "." + reference.Slot
+		}
+		fmt.Fprintln(w, reference.Kind+"\t"+reference.File+"\t"+reachedBy+"\t"+placedIn)
+	}
+	w.Flush()
+	utils.DisplayInfo("Cartridge "+cartridgeID+" is reached by "+strconv.Itoa(len(impact.References))+" references on "+
+		strconv.Itoa(len(impact.Pages))+" pages of "+strconv.Itoa(len(impact.Sites))+" sites and "+
+		strconv.Itoa(len(impact.Rules))+" content rules.", DisableColor)
+
+	if impactOutputPath != "" {
+		b, err := json.MarshalIndent(impact, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(impactOutputPath, append(b, '\n'), 0644)
+		}
+		if err != nil {
+			utils.DisplayError("Couldn't write the impact to "+impactOutputPath, err, DisableColor)
+			return app, exitOutput
+		}
+		utils.DisplayInfo("Created impact file at "+impactOutputPath, DisableColor)
+	}
+	return app, exitCode
+}
+
+// listOrNone joins the values for display, - when there are none
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ", ")
+}
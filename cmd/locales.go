@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+var coverageOutputPath string
+
+// localesCmd represents the locales command
+var localesCmd = &cobra.Command{
+	Use:   "locales [path to application export zip]...",
+	Short: "locales audits the localization of the cartridge templates of an Endeca Application",
+	Long: `locales collects every ${key} placeholder of each template.xml and checks every
+locales/Resources_<locale>.properties of the template for keys that are missing,
+empty or unused. A key is present in a locale when the locale, a parent of it
+(de for de_CH) or Resources.properties defines it. Placeholders in the values of
+used keys count as used as well. Templates with only Resources.properties have
+that file checked instead.
+It exits with status 1 when keys are missing or empty in any locale.
+For example:
+    cartridgemapp locales /full/path/to/endeca/exported/Application.zip
+    cartridgemapp locales /full/path/to/endeca/exported/Application.zip --coverage-output locale-coverage.json
+`,
+	Example: "cartridgemapp locales /full/path/to/endeca/exported/Application.zip --coverage-output locale-coverage.json",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var endecaAppPaths []string = args
+		start := time.Now()
+		app, exitCode := auditEndecaAppLocales(endecaAppPaths)
+		finishRun(start, app, exitCode)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(localesCmd)
+	localesCmd.Flags().StringVarP(&coverageOutputPath, "coverage-output", "", "", "JSON file to write the localization coverage of every template to")
+	addIgnoreFlags(localesCmd)
+	addAppRootFlag(localesCmd)
+}
+
+// auditEndecaAppLocales audits the locales of the templates and displays the missing, empty
+// and unused keys. It returns exitValidation when keys are missing or empty and exitParse when
+// templates or their locale files couldn't be read.
+func auditEndecaAppLocales(endecaAppPaths []string) (endeca.Application, int) {
+	app, exitCode := mapApplication(endecaAppPaths)
+	if exitCode == exitInput {
+		return app, exitCode
+	}
+	failuresBefore := endeca.ParseFailures()
+	audits := endeca.AuditLocales(extractDirectory, DisableColor, Debug)
+	if endeca.ParseFailures() > failuresBefore {
+		exitCode = maxExitCode(exitCode, exitParse)
+	}
+
+	var missing, empty, unused int
+	for _, audit := range audits {
+		if len(audit.GetLocales()) == 0 {
+			utils.DisplayWarning("templates/"+audit.GetTemplate()+": uses "+strings.Join(audit.GetKeys(), ", ")+" but has no locale files", DisableColor)
+			missing += len(audit.GetKeys())
+		}
+		for _, coverage := range audit.GetLocales() {
+			if keys := coverage.GetMissing(); len(keys) > 0 {
+				utils.DisplayWarning(coverage.GetPath()+": missing "+strings.Join(keys, ", "), DisableColor)
+				missing += len(keys)
+			}
+			if keys := coverage.GetEmpty(); len(keys) > 0 {
+				utils.DisplayWarning(coverage.GetPath()+": empty "+strings.Join(keys, ", "), DisableColor)
+				empty += len(keys)
+			}
+			if keys := coverage.GetUnused(); len(keys) > 0 {
+				utils.DisplayInfo(coverage.GetPath()+": unused "+strings.Join(keys, ", "), DisableColor)
+				unused += len(keys)
+			}
+		}
+	}
+
+	if coverageOutputPath != "" {
+		if audits == nil {
+			audits = []endeca.TemplateLocales{}
+		}
+		b, err := json.MarshalIndent(audits, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(coverageOutputPath, append(b, '\n'), 0644)
+		}
+		if err != nil {
+			utils.DisplayError("Couldn't write localization coverage to "+coverageOutputPath, err, DisableColor)
+			return app, exitOutput
+		}
+		utils.DisplayInfo("Created localization coverage file at "+coverageOutputPath, DisableColor)
+	}
+
+	message := "Localization audit found " + strconv.Itoa(missing) + " missing, " + strconv.Itoa(empty) +
+		" empty and " + strconv.Itoa(unused) + " unused keys."
+	if missing+empty > 0 {
+		utils.DisplayError(message, nil, DisableColor)
+		return app, maxExitCode(exitCode, exitValidation)
+	}
+	utils.DisplayInfo(message, DisableColor)
+	return app, exitCode
+}
@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/johnroach/cartridgemapper/endeca"
 	"github.com/johnroach/cartridgemapper/templates"
@@ -10,56 +13,275 @@ import (
 )
 
 var outputPath string
+var outputFormat string
+var splitOutput bool
+var locationPrefix string
+var mergeInputs bool
+
+// outputFormats are the formats mapEndecaApp can write the endeca map in
+var outputFormats = []string{"html", "site", "markdown", "confluence", "csv", "xlsx", "sarif", "junit"}
 var templatePath string
+var assemblerConfigPath string
+var storefrontPath string
+var rendererPatterns []string
+var ignorePatterns []string
+var ignoreFile string
 
 // mapEndecaAppCmd represents the mapEndecaApp command
 var mapEndecaAppCmd = &cobra.Command{
-	Use:   "mapEndecaApp [path to application export zip]",
+	Use:   "mapEndecaApp [path to application export zip]...",
 	Short: "mapEndecaApp maps the Endeca cartridges used in an Endeca Application",
 	Long: `mapEndecaApp maps the Endeca cartridges used in an Endeca Application.
 The cartridges will be go through validation.
 For example:
     cartridgemapp mapEndecaApp /full/path/to/endeca/exported/Application.zip
-    cartridgemapp mapEndecaApp /full/path/to/endeca/exported/Application.zip --output json
+    cartridgemapp mapEndecaApp /full/path/to/endeca/exported/Application.zip --format site
+    cartridgemapp mapEndecaApp /full/path/to/endeca/exports/ --outputPath portfolio
+
+Given several exports, or a directory of export zips, every application is mapped
+into a directory named after its zip under the output path and a portfolio report
+(portfolio.html, or portfolio.md for the markdown format) links to them. Its
+matrix shows which cartridge IDs are shared by applications, diverged (defined
+differently) or unique to one application.
+
+With --merge the exports are parts of one application instead, e.g. a zip per
+site or separate templates and content zips, and are overlaid in order before
+mapping. A file that more than one export has with different content is a merge
+conflict: it is logged as an error, the file of the last export is kept and the
+run exits with status 3.
+
+With --history-dir the mapped model of every application is also saved as a
+snapshot in that directory, see the history command.
+
+The application root, the folder holding templates, content and pages, is
+found wherever it is in an export. Every application of an export holding
+several is mapped into the portfolio, unless --app-root picks one.
+
+Formats:
+    html        a single index.html with a table of the cartridges (default)
+    site        a static documentation site with a page per cartridge, site and page
+    markdown    GitHub flavored Markdown in cartridges.md, or a file per cartridge with --split
+    confluence  Confluence storage format XHTML in cartridges.xhtml
+    csv         cartridges.csv plus cartridge-pages.csv, cartridge-sites.csv and cartridge-rules.csv
+    xlsx        cartridges.xlsx with a sheet of cartridges and a sheet for each relation
+    sarif       validation findings as a SARIF 2.1.0 log in findings.sarif
+    junit       validation findings as a JUnit XML report in findings.junit.xml
+
+The sarif and junit formats leave out the findings accepted in the --baseline file.
+
+Exit codes:
+    0  the map was written
+    2  input error, e.g. an unknown format or a zip that can't be opened
+    3  parse error, e.g. an XML file can't be parsed or no cartridges were found
+    4  output error, the map couldn't be written
 `,
 	Example: "cartridgemapp mapEndecaApp /full/path/to/endeca/exported/Application.zip --debug",
 	Args:    cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		var endecaAppPath string = args[0]
-		mapEndecaApp(endecaAppPath)
+		start := time.Now()
+		endecaAppPaths, err := expandAppPaths(args)
+		if err != nil {
+			utils.DisplayError("Couldn't find the exports to map.", err, DisableColor)
+			finishRun(start, endeca.Application{}, exitInput)
+			return
+		}
+		sources, err := applicationSources(endecaAppPaths, mergeInputs)
+		if err != nil {
+			utils.DisplayError("Couldn't find the applications in the exports.", err, DisableColor)
+			finishRun(start, endeca.Application{}, exitInput)
+			return
+		}
+		if len(sources) == 1 {
+			app, exitCode := mapEndecaApp(sources[0], outputPath)
+			finishRun(start, app, exitCode)
+			return
+		}
+		app, exitCode := mapEndecaPortfolio(sources)
+		finishRun(start, app, exitCode)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(mapEndecaAppCmd)
 	mapEndecaAppCmd.Flags().StringVarP(&outputPath, "outputPath", "o", ".", "Output path for the endeca map")
+	mapEndecaAppCmd.Flags().StringVarP(&outputFormat, "format", "f", "html", "Output format of the endeca map: "+strings.Join(outputFormats, ", "))
+	mapEndecaAppCmd.Flags().BoolVarP(&splitOutput, "split", "", false, "Write a Markdown file per cartridge")
+	mapEndecaAppCmd.Flags().StringVarP(&locationPrefix, "location-prefix", "", "", "Path of the export in the repository, prefixed to the file locations of sarif and junit findings")
 	mapEndecaAppCmd.Flags().StringVarP(&templatePath, "templatePath", "", "", "Template path for the endeca map")
+	mapEndecaAppCmd.Flags().BoolVarP(&mergeInputs, "merge", "", false, "Overlay several exports into one application instead of mapping a portfolio")
+	addBaselineFlag(mapEndecaAppCmd)
+	addLinkFlags(mapEndecaAppCmd)
+	addIgnoreFlags(mapEndecaAppCmd)
+	addAppRootFlag(mapEndecaAppCmd)
+	addHistoryFlags(mapEndecaAppCmd)
+}
+
+// addLinkFlags adds the flags for linking cartridges to handlers and renderers to a command
+func addLinkFlags(command *cobra.Command) {
+	command.Flags().StringVarP(&assemblerConfigPath, "assembler-config", "", "", "Assembler Spring config (assembler-context.xml) to map cartridge handlers from")
+	command.Flags().StringVarP(&storefrontPath, "storefront", "", "", "Storefront source directory to check cartridge renderers against")
+	command.Flags().StringArrayVarP(&rendererPatterns, "renderer-pattern", "", nil, "Regular expression matching JS component map entries, the first capture group being the cartridge ID")
+}
+
+// addIgnoreFlags adds the flags for leaving template, content and page paths out of the scans to a command
+func addIgnoreFlags(command *cobra.Command) {
+	command.Flags().StringArrayVarP(&ignorePatterns, "ignore", "", nil, "Glob of template, content or page paths to leave out, relative to the application, e.g. pages/TestSite")
+	command.Flags().StringVarP(&ignoreFile, "ignore-file", "", endeca.IgnoreFile, "File of ignore globs, one per line")
+}
+
+// loadIgnorePatterns sets the ignore patterns from the flags and the ignore file. The
+// default ignore file doesn't have to exist.
+func loadIgnorePatterns() bool {
+	patterns := ignorePatterns
+	filePatterns, err := endeca.ReadIgnoreFile(ignoreFile)
+	if err != nil && !(os.IsNotExist(err) && ignoreFile == endeca.IgnoreFile) {
+		utils.DisplayError("Couldn't read ignore file "+ignoreFile, err, DisableColor)
+		return false
+	}
+	patterns = append(patterns, filePatterns...)
+	if err := endeca.SetIgnorePatterns(patterns); err != nil {
+		utils.DisplayError("Couldn't use ignore patterns.", err, DisableColor)
+		return false
+	}
+	if len(patterns) > 0 {
+		utils.DisplayDebug("Ignoring "+strings.Join(patterns, ", "), Debug, DisableColor)
+	}
+	return true
+}
+
+// mapEndecaApp maps the application and writes it in the output format to the output
+// directory, returning the exit code
+func mapEndecaApp(source appSource, outputDirectory string) (endeca.Application, int) {
+	if !isOutputFormat(outputFormat) {
+		utils.DisplayError("Unknown output format "+outputFormat+", use one of "+strings.Join(outputFormats, ", ")+".", nil, DisableColor)
+		return endeca.Application{}, exitInput
+	}
+	app, exitCode := mapSource(source)
+	if exitCode == exitInput {
+		return app, exitCode
+	}
+	if !saveSnapshot(app, source.name) {
+		return app, exitOutput
+	}
+	var outputError error
+	switch outputFormat {
+	case "site":
+		outputError = templates.SiteOutputHTML(app, assemblerConfigPath != "", storefrontPath != "", outputDirectory, DisableColor, Debug)
+	case "markdown":
+		outputError = templates.CartridgeOutputMarkdown(app, splitOutput, outputDirectory, DisableColor, Debug)
+	case "confluence":
+		outputError = templates.CartridgeOutputConfluence(app, outputDirectory, DisableColor, Debug)
+	case "csv":
+		outputError = templates.CartridgeOutputCSV(app, outputDirectory, DisableColor, Debug)
+	case "xlsx":
+		outputError = templates.CartridgeOutputXLSX(app, outputDirectory, DisableColor, Debug)
+	case "sarif", "junit":
+		findings, validated := validateApplication(app)
+		if !validated {
+			return app, exitInput
+		}
+		if outputFormat == "sarif" {
+			outputError = templates.FindingsOutputSARIF(findings, locationPrefix, outputDirectory, DisableColor, Debug)
+		} else {
+			outputError = templates.FindingsOutputJUnit(findings, locationPrefix, outputDirectory, DisableColor, Debug)
+		}
+	default:
+		outputError = templates.CartridgeOutputHTML(app, assemblerConfigPath != "", storefrontPath != "", outputDirectory, DisableColor, Debug)
+	}
+	if outputError != nil {
+		return app, exitOutput
+	}
+	return app, exitCode
 }
 
-func mapEndecaApp(endecaAppPath string) {
-	dirError := os.MkdirAll(".remove_me", os.ModePerm)
+// isOutputFormat checks if format is one of the known output formats
+func isOutputFormat(format string) bool {
+	for _, outputFormat := range outputFormats {
+		if format == outputFormat {
+			return true
+		}
+	}
+	return false
+}
+
+// mapApplication finds the application in the exports, which are merged when there are
+// several, and maps it, see mapSource
+func mapApplication(endecaAppPaths []string) (endeca.Application, int) {
+	endecaAppPaths, err := expandAppPaths(endecaAppPaths)
+	var sources []appSource
+	if err == nil {
+		sources, err = applicationSources(endecaAppPaths, true)
+	}
+	if err != nil {
+		utils.DisplayError("Couldn't find the application in the exports.", err, DisableColor)
+		return endeca.Application{}, exitInput
+	}
+	return mapSource(sources[0])
+}
+
+// mapSource unzips the exported application and maps it. The cartridges are linked
+// to their handlers and renderers when an assembler config or storefront is given.
+// It returns exitInput when the application couldn't be mapped at all and exitParse when
+// files of the application couldn't be parsed, merged exports conflict or no cartridges
+// were found. Problems with the content, like missing descriptions, are left to Validate.
+func mapSource(source appSource) (endeca.Application, int) {
+	var app endeca.Application
+	if !loadIgnorePatterns() {
+		return app, exitInput
+	}
+	// start from an empty directory so files of an earlier run or application don't mix in
+	os.RemoveAll(extractDirectory)
+	dirError := os.MkdirAll(extractDirectory, os.ModePerm)
 	if dirError == nil {
-		_, error := utils.Unzip(endecaAppPath, ".remove_me")
+		failuresBefore := endeca.ParseFailures()
+		conflicts, error := unzipSource(source, extractDirectory)
 		if error == nil {
 			utils.DisplayInfo("Unzipped exported endeca application file...", DisableColor)
 
-			var cartridges = endeca.MapCartridges(".remove_me", DisableColor, Debug)
-			templates.CartridgeOutputHTML(cartridges, outputPath, DisableColor, Debug)
-			//removeDirectory(".remove_me")
+			app = endeca.MapApplication(extractDirectory, DisableColor, Debug)
+			if assemblerConfigPath != "" {
+				handlers, handlerError := endeca.MapHandlers(assemblerConfigPath, DisableColor, Debug)
+				if handlerError != nil {
+					return app, exitInput
+				}
+				app.Cartridges, app.OrphanHandlers = endeca.LinkHandlers(app.Cartridges, handlers, DisableColor, Debug)
+			}
+			if storefrontPath != "" {
+				renderers, rendererError := endeca.MapRenderers(storefrontPath, rendererPatterns, DisableColor, Debug)
+				if rendererError != nil {
+					return app, exitInput
+				}
+				app.Cartridges, app.OrphanRenderers = endeca.LinkRenderers(app.Cartridges, renderers, DisableColor, Debug)
+			}
+			//removeDirectory(extractDirectory)
 			utils.DisplayInfo("Removed temporary directory...", DisableColor)
-		} else {
-			utils.DisplayError("Couldn't unzip file.", error, DisableColor)
-			//removeDirectory(".remove_me")
+
+			if templates, content, pages := endeca.IgnoredCounts(); templates+content+pages > 0 {
+				utils.DisplayInfo("Ignored "+strconv.Itoa(templates)+" template directories, "+strconv.Itoa(content)+
+					" content paths and "+strconv.Itoa(pages)+" page paths matching ignore rules.", DisableColor)
+			}
+
+			if len(app.Cartridges) == 0 {
+				utils.DisplayError("No cartridges found in "+source.name+".", nil, DisableColor)
+				return app, exitParse
+			}
+			if conflicts > 0 || endeca.ParseFailures() > failuresBefore {
+				return app, exitParse
+			}
+			return app, exitOK
 		}
+		utils.DisplayError("Couldn't unzip file.", error, DisableColor)
+		//removeDirectory(extractDirectory)
 	} else {
 		utils.DisplayError("Couldn't create test directory.", dirError, DisableColor)
 	}
+	return app, exitInput
 }
 
 func removeDirectory(path string) error {
-	removeError := os.RemoveAll(".remove_me")
+	removeError := os.RemoveAll(path)
 	if removeError != nil {
-		utils.DisplayError("Couldn't remove .remove_me temp folder.", removeError, DisableColor)
+		utils.DisplayError("Couldn't remove "+path+" temp folder.", removeError, DisableColor)
 	}
 	return removeError
 }
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/templates"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// expandAppPaths returns the export zips to map: every argument that is a file and the
+// zips directly in every argument that is a directory, in name order
+func expandAppPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return paths, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		zips, err := filepath.Glob(filepath.Join(arg, "*.zip"))
+		if err != nil {
+			return paths, err
+		}
+		if len(zips) == 0 {
+			return paths, errors.New("no export zips in directory " + arg)
+		}
+		sort.Strings(zips)
+		paths = append(paths, zips...)
+	}
+	return paths, nil
+}
+
+// reportFile returns the file of the output format the portfolio links to
+func reportFile() string {
+	switch outputFormat {
+	case "markdown":
+		if splitOutput {
+			return "README.md"
+		}
+		return "cartridges.md"
+	case "confluence":
+		return "cartridges.xhtml"
+	case "csv":
+		return "cartridges.csv"
+	case "xlsx":
+		return "cartridges.xlsx"
+	case "sarif":
+		return "findings.sarif"
+	case "junit":
+		return "findings.junit.xml"
+	default:
+		return "index.html"
+	}
+}
+
+// mapEndecaPortfolio maps every application into a directory of its own under the output
+// path and writes the portfolio report comparing them. Applications that can't be read
+// are left out of the portfolio. It returns the applications combined, for the summary,
+// and the most severe exit code of the applications.
+func mapEndecaPortfolio(sources []appSource) (endeca.Application, int) {
+	var combined endeca.Application
+	if !isOutputFormat(outputFormat) {
+		utils.DisplayError("Unknown output format "+outputFormat+", use one of "+strings.Join(outputFormats, ", ")+".", nil, DisableColor)
+		return combined, exitInput
+	}
+
+	var apps []endeca.PortfolioApp
+	var reports []string
+	exitCode := exitOK
+	for _, source := range sources {
+		name := source.name
+		directory := filepath.Join(outputPath, name)
+		utils.DisplayInfo("Mapping application "+name+" from "+source.archives[0].Path+" into "+directory, DisableColor)
+		if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+			utils.DisplayError("Couldn't create output directory "+directory, err, DisableColor)
+			exitCode = maxExitCode(exitCode, exitOutput)
+			continue
+		}
+		app, appExitCode := mapEndecaApp(source, directory)
+		exitCode = maxExitCode(exitCode, appExitCode)
+		if appExitCode == exitInput {
+			continue
+		}
+		apps = append(apps, endeca.PortfolioApp{Name: name, App: app})
+		reports = append(reports, name+"/"+reportFile())
+		combined.Cartridges = append(combined.Cartridges, app.Cartridges...)
+		combined.Sites = append(combined.Sites, app.Sites...)
+		combined.Pages = append(combined.Pages, app.Pages...)
+		combined.Rules = append(combined.Rules, app.Rules...)
+	}
+	if len(apps) == 0 {
+		utils.DisplayError("None of the applications could be mapped.", nil, DisableColor)
+		return combined, exitCode
+	}
+
+	portfolio := endeca.NewPortfolio(apps)
+	var outputError error
+	if outputFormat == "markdown" {
+		outputError = templates.PortfolioOutputMarkdown(portfolio, reports, outputPath, DisableColor, Debug)
+	} else {
+		outputError = templates.PortfolioOutputHTML(portfolio, reports, outputPath, DisableColor, Debug)
+	}
+	if outputError != nil {
+		return combined, maxExitCode(exitCode, exitOutput)
+	}
+	utils.DisplayInfo("Portfolio of "+strconv.Itoa(len(apps))+" applications: "+
+		strconv.Itoa(portfolio.StatusCount(endeca.PortfolioShared))+" shared, "+
+		strconv.Itoa(portfolio.StatusCount(endeca.PortfolioDiverged))+" diverged and "+
+		strconv.Itoa(portfolio.StatusCount(endeca.PortfolioUnique))+" unique cartridges.", DisableColor)
+	return combined, exitCode
+}
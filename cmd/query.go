@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+var queryFormat string
+var queryOutputPath string
+
+// queryFormats are the formats query can write the rows in
+var queryFormats = []string{"table", "json", "csv"}
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query [expression] [path to application export zip]...",
+	Short: "query selects cartridges, sites, pages or rules of an Endeca Application with an expression",
+	Long: `query maps the application and selects the cartridges, sites, pages or rules
+matching an expression:
+
+    <cartridges|sites|pages|rules> [where <condition>] [select <field>, ...]
+
+Conditions compare fields, len(<field>), "strings" and numbers with ==, !=, <,
+<=, > and >=, test lists and strings with contains (case insensitive) and
+matches (a regular expression), and combine with and, or, not and parentheses.
+
+Fields:
+    cartridges  id, type, description, path, sites, pages, rules, properties, handler, renderers
+    sites       id, name, url, defaultPage, pages, cartridges
+    pages       key, site, name, path, cartridges, rules
+    rules       path, cartridges, pages
+
+The rows are written as a table, JSON or CSV to stdout, or to --query-output.
+Use --log-level error to pipe them.
+For example:
+    cartridgemapp query 'cartridges where sites contains "Mobile" and len(rules) == 0' Application.zip
+    cartridgemapp query 'pages where cartridges contains "HeroBanner" select key, path' Application.zip --query-format csv
+`,
+	Example: `cartridgemapp query 'cartridges where len(pages) == 0 select id, path' Application.zip --query-format json`,
+	Args:    cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		start := time.Now()
+		app, exitCode := runQuery(args[0], args[1:])
+		finishRun(start, app, exitCode)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+	queryCmd.Flags().StringVarP(&queryFormat, "query-format", "", "table", "Output format of the rows: "+strings.Join(queryFormats, ", "))
+	queryCmd.Flags().StringVarP(&queryOutputPath, "query-output", "", "", "File to write the rows to instead of stdout")
+	addIgnoreFlags(queryCmd)
+	addAppRootFlag(queryCmd)
+}
+
+// runQuery parses the query, maps the application and writes the rows the query selects
+func runQuery(expression string, endecaAppPaths []string) (endeca.Application, int) {
+	var app endeca.Application
+	validFormat := false
+	for _, format := range queryFormats {
+		validFormat = validFormat || format == queryFormat
+	}
+	if !validFormat {
+		utils.DisplayError("Unknown query format "+queryFormat+", use one of "+strings.Join(queryFormats, ", ")+".", nil, DisableColor)
+		return app, exitInput
+	}
+	query, err := endeca.ParseQuery(expression)
+	if err != nil {
+		utils.DisplayError("Couldn't parse the query.", err, DisableColor)
+		return app, exitInput
+	}
+	app, exitCode := mapApplication(endecaAppPaths)
+	if exitCode == exitInput {
+		return app, exitCode
+	}
+	result, err := query.Run(app)
+	if err != nil {
+		utils.DisplayError("Couldn't run the query.", err, DisableColor)
+		return app, exitInput
+	}
+
+	var out io.Writer = os.Stdout
+	if queryOutputPath != "" {
+		file, err := os.Create(queryOutputPath)
+		if err != nil {
+			utils.DisplayError("Couldn't write the query result to "+queryOutputPath, err, DisableColor)
+			return app, exitOutput
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := writeQueryResult(out, result, queryFormat); err != nil {
+		utils.DisplayError("Couldn't write the query result.", err, DisableColor)
+		return app, exitOutput
+	}
+	utils.DisplayInfo("Query selected "+strconv.Itoa(len(result.Rows))+" rows.", DisableColor)
+	return app, exitCode
+}
+
+// writeQueryResult writes the rows as a table, JSON or CSV. Lists are joined with commas
+// in tables and semicolons in CSV.
+func writeQueryResult(out io.Writer, result endeca.QueryResult, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(result.Rows, "", "  ")
+		if err == nil {
+			_, err = out.Write(append(b, '\n'))
+		}
+		return err
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Write(result.Columns)
+		for _, row := range result.Rows {
+			var record []string
+			for _, column := range result.Columns {
+				record = append(record, endeca.FormatQueryValue(row[column], ";"))
+			}
+			w.Write(record)
+		}
+		w.Flush()
+		return w.Error()
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(result.Columns, "\t")))
+	for _, row := range result.Rows {
+		var values []string
+		for _, column := range result.Columns {
+			values = append(values, endeca.FormatQueryValue(row[column], ", "))
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
+}
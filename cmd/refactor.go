@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+var renameTemplate string
+var removeTemplate string
+var refactorOutputPath string
+var changeReportPath string
+
+// refactorCmd represents the refactor command
+var refactorCmd = &cobra.Command{
+	Use:   "refactor [path to application export zip]",
+	Short: "refactor renames or removes a cartridge across the content of an Endeca Application",
+	Long: `refactor writes a copy of an export with a cartridge renamed or removed across
+the content.xml files of content and pages, plus a JSON report of every change.
+The export itself is never modified.
+
+--rename OLD=NEW rewrites the TemplateId of every content item of OLD to NEW
+and the id of the template.xml with id OLD. Its directory is renamed as well when
+it is templates/OLD. It fails when a template already has the id NEW.
+--remove ID strips every content item of ID, with what is placed in it, from
+the content and pages. Content items at the root of a page or rule are left in
+and reported as skipped, the page or rule has to be removed by hand.
+
+Only the TemplateIds and content items changed are rewritten, every other byte
+of the files stays as it is. Unless --change-report says otherwise the report is
+written next to the new export as <name>-changes.json. It exits with status 1
+when content items were skipped.
+For example:
+    cartridgemapp refactor Application.zip --rename HeroBanner=Hero --refactor-output Application-renamed.zip
+    cartridgemapp refactor Application.zip --remove LegacyPromo --refactor-output Application-cleaned.zip
+`,
+	Example: "cartridgemapp refactor Application.zip --rename HeroBanner=Hero --refactor-output Application-renamed.zip",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		start := time.Now()
+		exitCode := refactorExport(args[0])
+		finishRun(start, endeca.Application{}, exitCode)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(refactorCmd)
+	refactorCmd.Flags().StringVarP(&renameTemplate, "rename", "", "", "Rename a cartridge, as OLD=NEW")
+	refactorCmd.Flags().StringVarP(&removeTemplate, "remove", "", "", "Remove the content items of a cartridge")
+	refactorCmd.Flags().StringVarP(&refactorOutputPath, "refactor-output", "", "", "Path of the export zip to write")
+	refactorCmd.Flags().StringVarP(&changeReportPath, "change-report", "", "", "JSON file to write the changes to, <refactor-output name>-changes.json by default")
+	addAppRootFlag(refactorCmd)
+}
+
+// refactorExport writes the refactored copy of the export and the change report, returning
+// the exit code
+func refactorExport(endecaAppPath string) int {
+	report := endeca.RefactorReport{Input: endecaAppPath, Output: refactorOutputPath, Changes: []endeca.ContentChange{}}
+	switch {
+	case (renameTemplate == "") == (removeTemplate == ""):
+		utils.DisplayError("Pass either --rename OLD=NEW or --remove ID.", nil, DisableColor)
+		return exitInput
+	case renameTemplate != "":
+		parts := strings.SplitN(renameTemplate, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" || strings.Contains(parts[1], "/") {
+			utils.DisplayError("--rename has to be OLD=NEW, got "+renameTemplate, nil, DisableColor)
+			return exitInput
+		}
+		report.Action, report.Template, report.NewTemplate = endeca.ChangeRename, strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	default:
+		report.Action, report.Template = endeca.ChangeRemove, removeTemplate
+	}
+	if refactorOutputPath == "" {
+		utils.DisplayError("Pass the export zip to write with --refactor-output.", nil, DisableColor)
+		return exitInput
+	}
+	if samePath(endecaAppPath, refactorOutputPath) {
+		utils.DisplayError("--refactor-output can't be the export itself, it is never modified in place.", nil, DisableColor)
+		return exitInput
+	}
+	reportPath := changeReportPath
+	if reportPath == "" {
+		reportPath = strings.TrimSuffix(refactorOutputPath, filepath.Ext(refactorOutputPath)) + "-changes.json"
+	}
+
+	roots, err := exportRoots(endecaAppPath)
+	if err == nil && len(roots) > 1 {
+		err = errors.New(endecaAppPath + " holds " + strconv.Itoa(len(roots)) + " applications, pick one with --app-root")
+	}
+	if err != nil {
+		utils.DisplayError("Couldn't find the application in the export.", err, DisableColor)
+		return exitInput
+	}
+	prefix := ""
+	if roots[0] != "" {
+		prefix = roots[0] + "/"
+	}
+	var moveTemplate bool
+	if report.Action == endeca.ChangeRename {
+		if moveTemplate, err = checkRenameTemplate(endecaAppPath, prefix, report.Template, report.NewTemplate); err != nil {
+			utils.DisplayError("Couldn't rename "+report.Template+".", err, DisableColor)
+			return exitInput
+		}
+	}
+
+	exitCode := exitOK
+	oldTemplateDirectory := prefix + "templates/" + report.Template + "/"
+	renamePath := func(name string) string {
+		if moveTemplate && strings.HasPrefix(name, oldTemplateDirectory) {
+			return prefix + "templates/" + report.NewTemplate + "/" + strings.TrimPrefix(name, oldTemplateDirectory)
+		}
+		return name
+	}
+	err = utils.RewriteZip(endecaAppPath, refactorOutputPath, renamePath, func(name string, content []byte) (string, []byte, error) {
+		if !strings.HasPrefix(name, prefix) {
+			return name, content, nil
+		}
+		file := strings.TrimPrefix(name, prefix)
+		var changes []endeca.ContentChange
+		var rewriteError error
+		switch {
+		case report.Action == endeca.ChangeRename && strings.HasPrefix(file, "templates/") && path.Base(file) == "template.xml":
+			var renamed bool
+			content, renamed, rewriteError = endeca.RenameTemplateDefinition(content, report.Template, report.NewTemplate)
+			if renamed {
+				changes = append(changes, endeca.ContentChange{File: file, Action: endeca.ChangeRename,
+					Template: report.Template, Detail: "template id " + report.Template + " -> " + report.NewTemplate})
+			}
+		case (strings.HasPrefix(file, "content/") || strings.HasPrefix(file, "pages/")) && path.Base(file) == "content.xml":
+			var rewritten []byte
+			if report.Action == endeca.ChangeRename {
+				rewritten, changes, rewriteError = endeca.RenameTemplate(content, file, report.Template, report.NewTemplate)
+			} else {
+				rewritten, changes, rewriteError = endeca.RemoveTemplate(content, file, report.Template)
+			}
+			if rewriteError == nil {
+				content = rewritten
+			}
+		}
+		if rewriteError != nil {
+			utils.WithFields(utils.Fields{"file": file}).Error("Couldn't parse XML for refactoring, copied it unchanged", rewriteError)
+			changes = append(changes, endeca.ContentChange{File: file, Action: endeca.ChangeSkip, Template: report.Template,
+				Detail: "couldn't parse the file: " + rewriteError.Error()})
+			exitCode = maxExitCode(exitCode, exitParse)
+		}
+		report.Changes = append(report.Changes, changes...)
+		if newName := renamePath(name); newName != name {
+			if strings.TrimPrefix(newName, prefix) == "templates/"+report.NewTemplate+"/template.xml" {
+				report.Changes = append(report.Changes, endeca.ContentChange{File: strings.TrimSuffix(oldTemplateDirectory, "/"),
+					Action: endeca.ChangeRename, Template: report.Template, Detail: "moved to templates/" + report.NewTemplate})
+			}
+			return newName, content, nil
+		}
+		return name, content, nil
+	})
+	if err != nil {
+		utils.DisplayError("Couldn't write the refactored export to "+refactorOutputPath, err, DisableColor)
+		return exitOutput
+	}
+
+	for _, change := range report.Changes {
+		message := change.File
+		if change.Line > 0 {
+			message += ":" + strconv.Itoa(change.Line)
+		}
+		message += " " + change.Action + " " + change.Detail
+		if change.Name != "" {
+			message += " (" + change.Name + ")"
+		}
+		if change.Action == endeca.ChangeSkip {
+			utils.DisplayWarning(message, DisableColor)
+		} else {
+			utils.DisplayInfo(message, DisableColor)
+		}
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(reportPath, append(b, '\n'), 0644)
+	}
+	if err != nil {
+		utils.DisplayError("Couldn't write the change report to "+reportPath, err, DisableColor)
+		return exitOutput
+	}
+	changed := report.Count(endeca.ChangeRename) + report.Count(endeca.ChangeRemove)
+	utils.DisplayInfo("Wrote "+refactorOutputPath+" with "+strconv.Itoa(changed)+" changes and the change report "+reportPath, DisableColor)
+	if changed == 0 {
+		utils.DisplayWarning("The export has no template or content items of "+report.Template+".", DisableColor)
+	}
+	if skipped := report.Count(endeca.ChangeSkip); skipped > 0 {
+		utils.DisplayError(strconv.Itoa(skipped)+" changes have to be made by hand, see the change report.", nil, DisableColor)
+		return maxExitCode(exitCode, exitValidation)
+	}
+	return exitCode
+}
+
+// checkRenameTemplate reads the ID of every template of the export to check that no template
+// has the new ID yet. The cartridge ID is the id of the template.xml, not the template
+// directory, so the directory is only moved, which it returns, when it is named after the old
+// ID and its template has that ID.
+func checkRenameTemplate(endecaAppPath string, prefix string, oldID string, newID string) (bool, error) {
+	definitions, err := utils.ReadZipFiles(endecaAppPath, func(name string) bool {
+		file := strings.TrimPrefix(name, prefix)
+		return strings.HasPrefix(name, prefix) && path.Base(file) == "template.xml" && path.Dir(path.Dir(file)) == "templates"
+	})
+	if err != nil {
+		return false, err
+	}
+	var move, newDirectory bool
+	for name, content := range definitions {
+		directory := path.Base(path.Dir(name))
+		// unparsable templates keep their directory name as ID, their id is reported as skipped
+		templateID, _ := endeca.TemplateID(content, directory)
+		if templateID == newID {
+			return false, errors.New("the export already has a template " + newID + " in templates/" + directory)
+		}
+		if directory == oldID && templateID == oldID {
+			move = true
+		}
+		if directory == newID {
+			newDirectory = true
+		}
+	}
+	if move && newDirectory {
+		return false, errors.New("the export already has a templates/" + newID + " directory")
+	}
+	return move, nil
+}
+
+// samePath checks if two paths are the same file
+func samePath(first string, second string) bool {
+	firstAbs, firstErr := filepath.Abs(first)
+	secondAbs, secondErr := filepath.Abs(second)
+	return firstErr == nil && secondErr == nil && firstAbs == secondAbs
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+func TestRefactorRenameUsesTemplateIDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "refactor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the HeroBanner template lives in templates/Hero
+	export := filepath.Join(dir, "app.zip")
+	fo, err := os.Create(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(fo)
+	for name, content := range map[string]string{
+		"templates/Hero/template.xml":    `<ContentTemplate type="MainContent" id="HeroBanner"/>`,
+		"templates/Promo/template.xml":   `<ContentTemplate type="MainContent" id="Promo"/>`,
+		"pages/Default/home/content.xml": `<ContentItem><TemplateId>HeroBanner</TemplateId></ContentItem>`,
+	} {
+		f, err := w.Create(name)
+		if err == nil {
+			_, err = f.Write([]byte(content))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fo.Close()
+	defer func() { renameTemplate, refactorOutputPath = "", "" }()
+
+	tests := []struct {
+		rename   string
+		exitCode int
+		// files of the refactored export and a text they contain
+		files map[string]string
+	}{
+		{"HeroBanner=Banner", exitOK, map[string]string{
+			"templates/Hero/template.xml":    `id="Banner"`,
+			"pages/Default/home/content.xml": "<TemplateId>Banner</TemplateId>",
+		}},
+		// templates/Hero isn't the template of a cartridge Hero
+		{"Hero=Banner", exitOK, map[string]string{
+			"templates/Hero/template.xml": `id="HeroBanner"`,
+		}},
+		{"Promo=Teaser", exitOK, map[string]string{
+			"templates/Teaser/template.xml": `id="Teaser"`,
+		}},
+		{"Promo=HeroBanner", exitInput, nil},
+		{"Promo=Hero", exitInput, nil},
+	}
+	for index, test := range tests {
+		renameTemplate = test.rename
+		refactorOutputPath = filepath.Join(dir, "out"+strconv.Itoa(index)+".zip")
+		if exitCode := refactorExport(export); exitCode != test.exitCode {
+			t.Errorf("--rename %s exited with %d, expected %d", test.rename, exitCode, test.exitCode)
+			continue
+		}
+		if test.files == nil {
+			continue
+		}
+		files, err := utils.ReadZipFiles(refactorOutputPath, func(name string) bool { return true })
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, text := range test.files {
+			if !strings.Contains(string(files[name]), text) {
+				t.Errorf("--rename %s wrote %s as %q, expected it to contain %s", test.rename, name, files[name], text)
+			}
+		}
+	}
+}
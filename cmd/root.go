@@ -3,7 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/johnroach/cartridgemapper/utils"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -21,6 +24,17 @@ var Debug bool
 // DisableColor disables the color for a given run
 var DisableColor bool
 
+// logLevel, logFormat and logFile configure the logging of a run
+var logLevel string
+var logFormat string
+var logFile string
+
+// localeFallback is the locale chain descriptions fall back to, see endeca.LocaleFallback
+var localeFallback []string
+
+// progressMode is how progress is reported, see utils.ConfigureProgress
+var progressMode string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "cartridgemapper",
@@ -32,7 +46,7 @@ get an ouput of a certain format.
 
 For example:
     cartridgemapp mapEndecaApp /full/path/to/endeca/App/lication
-    cartridgemapp mapEndecaApp /full/path/to/endeca/App/lication --output json
+    cartridgemapp mapEndecaApp /full/path/to/endeca/App/lication --format site
 `,
 }
 
@@ -40,32 +54,93 @@ For example:
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitInput)
 	}
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentPreRun = preRun
 
 	rootCmd.AddCommand(versionCmd)
 
 	// Persistent flags. Flags that will live for all subcommands.
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.cartridgemapper.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is .cartridgemapper.yaml in the current directory or a parent of it, then $HOME)")
 	rootCmd.PersistentFlags().BoolVarP(&Debug, "debug", "", false, "adding debug to the logging")
-	rootCmd.PersistentFlags().BoolVarP(&DisableColor, "disable-color", "", false, "disable color for logging output")
+	rootCmd.PersistentFlags().BoolVarP(&DisableColor, "disable-color", "", false, "disable color for logging output, also disabled when NO_COLOR is set or the output isn't a terminal")
+	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "", "info", "log level: error, warn, info, debug or trace")
+	rootCmd.PersistentFlags().StringVarP(&logFormat, "log-format", "", utils.LogFormatText, "log format: text or json")
+	rootCmd.PersistentFlags().StringVarP(&logFile, "log-file", "", "", "file to append the log to, next to the terminal output")
+	rootCmd.PersistentFlags().StringVarP(&progressMode, "progress", "", utils.ProgressAuto, "progress reporting on stderr: auto (a bar on terminals), bar, json or none")
+	rootCmd.PersistentFlags().IntVarP(&workers, "workers", "", 1, "number of templates scanned at the same time")
+	rootCmd.PersistentFlags().StringSliceVarP(&localeFallback, "locale-fallback", "", []string{"en"}, "locales descriptions fall back to in order, the first one is the locale descriptions are shown in")
+}
+
+// preRun reads the config file and environment into the options that weren't given as
+// flags and configures logging, before any command runs
+func preRun(cmd *cobra.Command, args []string) {
+	if cmd.Parent() != configCmd {
+		err := initConfig()
+		if err == nil {
+			err = applyConfig(cmd)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(exitInput)
+		}
+	}
+	initLogging()
+	if viper.ConfigFileUsed() != "" {
+		utils.DisplayInfo("Using config file: "+viper.ConfigFileUsed(), DisableColor)
+	}
 }
 
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
+// initLogging configures the logging and progress reporting from the flags. --debug raises the level to debug.
+func initLogging() {
+	level, err := utils.ParseLevel(logLevel)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(exitInput)
+	}
+	if Debug && level < utils.LevelDebug {
+		level = utils.LevelDebug
+	}
+	err = utils.ConfigureLogging(utils.LogOptions{
+		Level:        level,
+		Format:       logFormat,
+		File:         logFile,
+		DisableColor: DisableColor,
+	})
+	if err == nil {
+		err = utils.ConfigureProgress(progressMode)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(exitInput)
+	}
+}
+
+// initConfig reads in config file and ENV variables if set. Without --config the
+// .cartridgemapper config is looked for in the current directory and its parents, so
+// a project can keep its config next to its exports, and then in the home directory.
+func initConfig() error {
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
+		if dir, err := os.Getwd(); err == nil {
+			for {
+				viper.AddConfigPath(dir)
+				parent := filepath.Dir(dir)
+				if parent == dir {
+					break
+				}
+				dir = parent
+			}
+		}
 		// Find home directory.
 		home, err := homedir.Dir()
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return err
 		}
 
 		// Search config in home directory with name ".cartridgemapper" (without extension).
@@ -73,12 +148,18 @@ func initConfig() {
 		viper.SetConfigName(".cartridgemapper")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// read in environment variables that match, e.g. CARTRIDGEMAPPER_LOG_LEVEL for --log-level
+	viper.SetEnvPrefix("cartridgemapper")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
 
 	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	if err := viper.ReadInConfig(); err != nil {
+		if _, notFound := err.(viper.ConfigFileNotFoundError); !notFound {
+			return fmt.Errorf("couldn't read config file: %v", err)
+		}
 	}
+	return nil
 }
 
 var versionCmd = &cobra.Command{
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/server"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+var serveAddress string
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve [path to application export zip]...",
+	Short: "serve maps an Endeca Application and serves it as a web UI and JSON API",
+	Long: `serve maps the Endeca cartridges used in an Endeca Application once and serves
+an interactive browser of the cartridges, sites, pages and rules along with a
+read-only JSON API over the same data.
+For example:
+    cartridgemapp serve /full/path/to/endeca/exported/Application.zip
+    cartridgemapp serve /full/path/to/endeca/exported/Application.zip --address 0.0.0.0:9090
+    curl http://localhost:8080/api/cartridges
+`,
+	Example: "cartridgemapp serve /full/path/to/endeca/exported/Application.zip --debug",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var endecaAppPaths []string = args
+		start := time.Now()
+		app, exitCode := serveEndecaApp(endecaAppPaths)
+		finishRun(start, app, exitCode)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVarP(&serveAddress, "address", "", "localhost:8080", "Address to serve the web UI and JSON API on")
+	addLinkFlags(serveCmd)
+	addIgnoreFlags(serveCmd)
+	addAppRootFlag(serveCmd)
+}
+
+// serveEndecaApp maps the application and serves it until the server fails, returning the exit code
+func serveEndecaApp(endecaAppPaths []string) (endeca.Application, int) {
+	app, exitCode := mapApplication(endecaAppPaths)
+	if exitCode == exitInput {
+		return app, exitCode
+	}
+	if err := server.Serve(app, serveAddress, DisableColor, Debug); err != nil {
+		utils.DisplayError("Couldn't serve the cartridge map.", err, DisableColor)
+		return app, exitOutput
+	}
+	return app, exitCode
+}
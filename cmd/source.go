@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+// extractDirectory is where the exports are unzipped to be mapped. The application root of
+// every export ends up at the top of it.
+const extractDirectory = ".remove_me"
+
+// appRoot picks the application root of exports holding several applications
+var appRoot string
+
+// appSource is an application to map: the exports it is made of and its root in each of them
+type appSource struct {
+	name     string
+	archives []utils.Archive
+}
+
+// addAppRootFlag adds the flag for picking the application root of the exports to a command
+func addAppRootFlag(command *cobra.Command) {
+	command.Flags().StringVarP(&appRoot, "app-root", "", "", "Folder of the application in the exports when they hold several applications, . for the top of the export")
+}
+
+// rootName shows an application root, . for the top of the export
+func rootName(root string) string {
+	if root == "" {
+		return "."
+	}
+	return root
+}
+
+// exportRoots returns the application roots of an export, only the --app-root one when given
+func exportRoots(endecaAppPath string) ([]string, error) {
+	names, err := utils.ZipFileNames(endecaAppPath)
+	if err != nil {
+		return nil, err
+	}
+	roots := endeca.ApplicationRoots(names)
+	if len(roots) == 0 {
+		return nil, errors.New("no templates, content or pages directory in " + endecaAppPath)
+	}
+	if appRoot == "" {
+		return roots, nil
+	}
+	wanted := strings.Trim(path.Clean(filepath.ToSlash(appRoot)), "/")
+	if wanted == "." {
+		wanted = ""
+	}
+	var found []string
+	for _, root := range roots {
+		if root == wanted {
+			return []string{root}, nil
+		}
+		found = append(found, rootName(root))
+	}
+	return nil, errors.New("no application root " + appRoot + " in " + endecaAppPath + ", it has " + strings.Join(found, ", "))
+}
+
+// applicationSources returns the applications to map out of the exports. Merged exports are
+// parts of one application, so each of them has to hold a single application root.
+// Otherwise every application root of every export is an application of its own, named
+// after its export and, for exports of several applications, its root.
+func applicationSources(endecaAppPaths []string, merge bool) ([]appSource, error) {
+	var sources []appSource
+	var merged appSource
+	taken := make(map[string]bool)
+	for _, endecaAppPath := range endecaAppPaths {
+		roots, err := exportRoots(endecaAppPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, root := range roots {
+			if root != "" {
+				utils.DisplayInfo("Found application root "+root+" in "+endecaAppPath, DisableColor)
+			}
+		}
+		if merge {
+			if len(roots) > 1 {
+				var found []string
+				for _, root := range roots {
+					found = append(found, rootName(root))
+				}
+				return nil, errors.New(endecaAppPath + " holds " + strconv.Itoa(len(roots)) + " applications (" +
+					strings.Join(found, ", ") + "), pick one with --app-root")
+			}
+			merged.archives = append(merged.archives, utils.Archive{Path: endecaAppPath, Folder: roots[0]})
+			continue
+		}
+		base := strings.TrimSuffix(filepath.Base(endecaAppPath), filepath.Ext(endecaAppPath))
+		for _, root := range roots {
+			name := base
+			if len(roots) > 1 && root != "" {
+				name = base + "-" + strings.Replace(root, "/", "-", -1)
+			}
+			sources = append(sources, appSource{
+				name:     appName(name, taken),
+				archives: []utils.Archive{{Path: endecaAppPath, Folder: root}},
+			})
+		}
+	}
+	if merge && len(merged.archives) > 0 {
+		merged.name = appName(strings.TrimSuffix(filepath.Base(endecaAppPaths[0]), filepath.Ext(endecaAppPaths[0])), taken)
+		sources = append(sources, merged)
+	}
+	return sources, nil
+}
+
+// appName returns a name for an application, adding a number when the name is already taken
+func appName(name string, taken map[string]bool) string {
+	unique := name
+	for number := 2; taken[unique]; number++ {
+		unique = name + "-" + strconv.Itoa(number)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// unzipSource unzips the exports of an application into a directory. Several exports are
+// overlaid as parts of one application, logging every file they have with different
+// content as a conflict. It returns the number of conflicts.
+func unzipSource(source appSource, directory string) (int, error) {
+	conflicts, err := utils.UnzipAll(source.archives, directory)
+	if err != nil {
+		return 0, err
+	}
+	for _, conflict := range conflicts {
+		utils.WithFields(utils.Fields{"file": conflict.Path, "exports": conflict.Sources}).Error(
+			"Merge conflict, exports have different versions of the file, keeping the one of "+conflict.Sources[len(conflict.Sources)-1], nil)
+	}
+	if len(source.archives) > 1 {
+		utils.DisplayInfo("Merged "+strconv.Itoa(len(source.archives))+" exports with "+strconv.Itoa(len(conflicts))+" conflicts.", DisableColor)
+	}
+	return len(conflicts), nil
+}
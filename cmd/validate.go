@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+	"github.com/spf13/cobra"
+)
+
+var baselinePath string
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [path to application export zip]...",
+	Short: "validate checks an Endeca Application for problems and fails when any are found",
+	Long: `validate checks the templates, content and pages of an Endeca Application for
+missing descriptions, unused cartridges, dangling references and XML errors.
+It exits with status 1 when findings are found, see the exit codes below. With --baseline the findings
+accepted in the baseline file are ignored, so only new findings fail the run.
+Use the baseline command to create or update the baseline file.
+For example:
+    cartridgemapp validate /full/path/to/endeca/exported/Application.zip
+    cartridgemapp validate /full/path/to/endeca/exported/Application.zip --baseline cartridgemapper-baseline.json
+
+Exit codes:
+    0  no (new) findings
+    1  new findings
+    2  input error, e.g. the zip or baseline file can't be read
+    3  parse error, e.g. an XML file can't be parsed or no cartridges were found
+    4  output error
+`,
+	Example: "cartridgemapp validate /full/path/to/endeca/exported/Application.zip --baseline cartridgemapper-baseline.json",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var endecaAppPaths []string = args
+		start := time.Now()
+		app, exitCode := validateEndecaApp(endecaAppPaths)
+		finishRun(start, app, exitCode)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	addBaselineFlag(validateCmd)
+	addLinkFlags(validateCmd)
+	addIgnoreFlags(validateCmd)
+	addAppRootFlag(validateCmd)
+}
+
+// addBaselineFlag adds the flag for ignoring the findings accepted in a baseline file to a command
+func addBaselineFlag(command *cobra.Command) {
+	command.Flags().StringVarP(&baselinePath, "baseline", "", "", "Baseline file of accepted findings, only findings not in it are reported")
+}
+
+// validateEndecaApp validates the application and displays the findings that aren't in the
+// baseline. It returns exitValidation when there are any such findings.
+func validateEndecaApp(endecaAppPaths []string) (endeca.Application, int) {
+	app, exitCode := mapApplication(endecaAppPaths)
+	if exitCode == exitInput {
+		return app, exitCode
+	}
+	findings, validated := validateApplication(app)
+	if !validated {
+		return app, exitInput
+	}
+	for _, finding := range findings {
+		displayFinding(finding)
+	}
+	if len(findings) > 0 {
+		utils.DisplayError("Validation failed with "+strconv.Itoa(len(findings))+" new findings.", nil, DisableColor)
+		return app, maxExitCode(exitCode, exitValidation)
+	}
+	utils.DisplayInfo("Validation passed, no new findings.", DisableColor)
+	return app, exitCode
+}
+
+// validateApplication validates the unzipped application and drops the findings accepted
+// in the baseline file when one is given
+func validateApplication(app endeca.Application) ([]endeca.Finding, bool) {
+	findings := endeca.Validate(extractDirectory, app, DisableColor, Debug)
+	if baselinePath == "" {
+		return findings, true
+	}
+	baseline, err := endeca.ReadBaseline(baselinePath)
+	if err != nil {
+		utils.DisplayError("Couldn't read baseline file "+baselinePath, err, DisableColor)
+		return findings, false
+	}
+	newFindings := baseline.NewFindings(findings)
+	utils.DisplayInfo(strconv.Itoa(len(findings)-len(newFindings))+" findings accepted by baseline "+baselinePath+".", DisableColor)
+	return newFindings, true
+}
+
+// displayFinding logs a finding at the level matching its severity
+func displayFinding(finding endeca.Finding) {
+	location := finding.GetPath()
+	if finding.GetLine() > 0 {
+		location += ":" + strconv.Itoa(finding.GetLine())
+	}
+	message := location + ": " + finding.GetMessage() + " [" + finding.GetRuleID() + "]"
+	switch finding.GetSeverity() {
+	case endeca.SeverityError:
+		utils.DisplayError(message, nil, DisableColor)
+	case endeca.SeverityWarning:
+		utils.DisplayWarning(message, DisableColor)
+	default:
+		utils.DisplayInfo(message, DisableColor)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateWithFullBaselinePasses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(workingDirectory)
+
+	// the template has no template.description in its properties, a finding but no parse error
+	export := filepath.Join(dir, "app.zip")
+	fo, err := os.Create(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(fo)
+	for name, content := range map[string]string{
+		"templates/Banner/template.xml":                    `<ContentTemplate type="MainContent" id="Banner"><Description>${template.description}</Description></ContentTemplate>`,
+		"templates/Banner/locales/Resources_en.properties": "label.name=Banner\n",
+		"pages/Default/home/content.xml":                   `<ContentItem><TemplateId>Banner</TemplateId></ContentItem>`,
+	} {
+		f, err := w.Create(name)
+		if err == nil {
+			_, err = f.Write([]byte(content))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fo.Close()
+
+	baselineOutputPath = filepath.Join(dir, "cartridgemapper-baseline.json")
+	if _, exitCode := writeEndecaAppBaseline([]string{export}); exitCode != exitOK {
+		t.Fatalf("baseline exited with %d, expected %d", exitCode, exitOK)
+	}
+	baselinePath = baselineOutputPath
+	defer func() { baselinePath = "" }()
+	if _, exitCode := validateEndecaApp([]string{export}); exitCode != exitOK {
+		t.Errorf("validate with a full baseline exited with %d, expected %d", exitCode, exitOK)
+	}
+}
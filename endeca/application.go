@@ -0,0 +1,169 @@
+package endeca
+
+import (
+	"sort"
+)
+
+// Application is everything mapped out of an Endeca application
+type Application struct {
+	Cartridges []Cartridge `json:"cartridges"`
+	Sites      []Site      `json:"sites"`
+	Pages      []Page      `json:"pages"`
+	Rules      []Rule      `json:"rules"`
+	// OrphanHandlers are the cartridge handler beans of the assembler config without a template
+	OrphanHandlers []Handler `json:"orphanHandlers,omitempty"`
+	// OrphanRenderers are the storefront renderers for content item types without a template
+	OrphanRenderers []Renderer `json:"orphanRenderers,omitempty"`
+}
+
+// Rule is a content rule under the content directory and what uses it
+type Rule struct {
+	// path of the rule relative to the content directory
+	path string
+	// cartridges are the template IDs the rule holds content for
+	cartridges []string
+	// pages are the keys of the pages that pull content from the rule
+	pages []string
+}
+
+// MapApplication maps the cartridges, sites, pages and rules for a given base path
+func MapApplication(basePath string, DisableColor bool, Debug bool) Application {
+	var app = Application{
+		Cartridges: MapCartridges(basePath, DisableColor, Debug),
+		Sites:      MapSites(basePath, DisableColor, Debug),
+		Pages:      MapPages(basePath, DisableColor, Debug),
+	}
+	app.Rules = mapRules(app.Cartridges, app.Pages)
+	return app
+}
+
+// mapRules collects the rules used by the cartridges and pages
+func mapRules(cartridges []Cartridge, pages []Page) []Rule {
+	rules := make(map[string]*Rule)
+	getRule := func(path string) *Rule {
+		if _, ok := rules[path]; !ok {
+			rules[path] = &Rule{path: path}
+		}
+		return rules[path]
+	}
+
+	for _, cartridge := range cartridges {
+		for _, rulePath := range cartridge.rules {
+			rule := getRule(rulePath)
+			rule.cartridges = appendUnique(rule.cartridges, cartridge.id)
+		}
+	}
+	for _, page := range pages {
+		for _, rulePath := range page.rules {
+			rule := getRule(rulePath)
+			rule.pages = appendUnique(rule.pages, page.GetKey())
+		}
+	}
+
+	var ruleList []Rule
+	for _, rule := range rules {
+		ruleList = append(ruleList, *rule)
+	}
+	sort.Slice(ruleList, func(i, j int) bool { return ruleList[i].path < ruleList[j].path })
+	return ruleList
+}
+
+// FindCartridge returns the cartridge with the given ID
+func (a Application) FindCartridge(id string) (Cartridge, bool) {
+	for _, cartridge := range a.Cartridges {
+		if cartridge.id == id {
+			return cartridge, true
+		}
+	}
+	return Cartridge{}, false
+}
+
+// FindSite returns the site with the given ID
+func (a Application) FindSite(id string) (Site, bool) {
+	for _, site := range a.Sites {
+		if site.id == id {
+			return site, true
+		}
+	}
+	return Site{}, false
+}
+
+// FindPage returns the page with the given key, see Page.GetKey
+func (a Application) FindPage(key string) (Page, bool) {
+	for _, page := range a.Pages {
+		if page.GetKey() == key {
+			return page, true
+		}
+	}
+	return Page{}, false
+}
+
+// FindRule returns the rule with the given path
+func (a Application) FindRule(path string) (Rule, bool) {
+	for _, rule := range a.Rules {
+		if rule.path == path {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// PageCartridges returns the template IDs a page uses, directly or through its content rules
+func (a Application) PageCartridges(page Page) []string {
+	var cartridges []string
+	for _, cartridgeID := range page.cartridges {
+		cartridges = appendUnique(cartridges, cartridgeID)
+	}
+	for _, rulePath := range page.rules {
+		if rule, ok := a.FindRule(rulePath); ok {
+			for _, cartridgeID := range rule.cartridges {
+				cartridges = appendUnique(cartridges, cartridgeID)
+			}
+		}
+	}
+	return cartridges
+}
+
+// PagesUsing returns the pages using a cartridge, directly or through a content rule
+func (a Application) PagesUsing(cartridgeID string) []Page {
+	var pages []Page
+	for _, page := range a.Pages {
+		for _, pageCartridgeID := range a.PageCartridges(page) {
+			if pageCartridgeID == cartridgeID {
+				pages = append(pages, page)
+				break
+			}
+		}
+	}
+	return pages
+}
+
+// SiteCartridges returns the template IDs used on the pages of a site
+func (a Application) SiteCartridges(siteID string) []string {
+	var cartridges []string
+	for _, page := range a.Pages {
+		if page.site != siteID {
+			continue
+		}
+		for _, cartridgeID := range a.PageCartridges(page) {
+			cartridges = appendUnique(cartridges, cartridgeID)
+		}
+	}
+	sort.Strings(cartridges)
+	return cartridges
+}
+
+// GetPath returns the path of the rule relative to the content directory
+func (r Rule) GetPath() string {
+	return r.path
+}
+
+// GetCartridges returns the template IDs the rule holds content for
+func (r Rule) GetCartridges() []string {
+	return r.cartridges
+}
+
+// GetPages returns the keys of the pages pulling content from the rule
+func (r Rule) GetPages() []string {
+	return r.pages
+}
@@ -0,0 +1,242 @@
+package endeca
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// handlerBeanPrefix is the bean ID prefix the Assembler uses to look up the handler
+// for a content item. The content item type is the ID of its template, so the handler
+// for template HeroBanner is the bean CartridgeHandler_HeroBanner.
+const handlerBeanPrefix = "CartridgeHandler_"
+
+// springPNamespace is the Spring namespace for setting properties as attributes
+const springPNamespace = "http://www.springframework.org/schema/p"
+
+// Handler is a CartridgeHandler bean defined in the Assembler Spring configuration
+type Handler struct {
+	// beanID is the ID of the handler bean
+	beanID string
+	// className is the class of the handler, resolved through parent beans
+	className string
+	// scope of the bean
+	scope string
+	// config holds the properties the bean is configured with
+	config map[string]string
+}
+
+// springBeans is the root of a Spring XML configuration file
+type springBeans struct {
+	Beans []springBean `xml:"bean"`
+}
+
+// springBean is a single bean definition
+type springBean struct {
+	ID         string           `xml:"id,attr"`
+	Name       string           `xml:"name,attr"`
+	Class      string           `xml:"class,attr"`
+	Parent     string           `xml:"parent,attr"`
+	Scope      string           `xml:"scope,attr"`
+	Attrs      []xml.Attr       `xml:",any,attr"`
+	Properties []springProperty `xml:"property"`
+}
+
+// springProperty is a property set on a bean
+type springProperty struct {
+	Name  string       `xml:"name,attr"`
+	Value string       `xml:"value,attr"`
+	Ref   string       `xml:"ref,attr"`
+	Inner springValue  `xml:"value"`
+	InRef springRef    `xml:"ref"`
+	Bean  *springBean  `xml:"bean"`
+	List  *springInner `xml:"list"`
+	Map   *springInner `xml:"map"`
+}
+
+// springValue is a nested value element
+type springValue struct {
+	Value string `xml:",chardata"`
+}
+
+// springRef is a nested ref element
+type springRef struct {
+	Bean string `xml:"bean,attr"`
+}
+
+// springInner is a collection element, only kept as the number of entries it has
+type springInner struct {
+	Values  []springValue `xml:"value"`
+	Refs    []springRef   `xml:"ref"`
+	Entries []springValue `xml:"entry"`
+}
+
+// MapHandlers reads the CartridgeHandler beans out of an Assembler Spring configuration file
+func MapHandlers(assemblerConfigPath string, DisableColor bool, Debug bool) ([]Handler, error) {
+	var handlers []Handler
+
+	b, err := ioutil.ReadFile(assemblerConfigPath)
+	if err != nil {
+		utils.WithFields(utils.Fields{"file": assemblerConfigPath}).Error("Couldn't read assembler config", err)
+		return handlers, err
+	}
+
+	var config springBeans
+	if err := xml.Unmarshal(b, &config); err != nil {
+		utils.WithFields(utils.Fields{"file": assemblerConfigPath}).Error("Couldn't parse assembler config", err)
+		return handlers, err
+	}
+
+	beans := make(map[string]springBean)
+	for _, bean := range config.Beans {
+		beans[bean.getID()] = bean
+	}
+
+	for _, bean := range config.Beans {
+		beanID := bean.getID()
+		if !strings.HasPrefix(beanID, handlerBeanPrefix) {
+			continue
+		}
+		utils.DisplayDebug("Found cartridge handler bean "+beanID, Debug, DisableColor)
+		handlers = append(handlers, Handler{
+			beanID:    beanID,
+			className: resolveBeanClass(bean, beans),
+			scope:     bean.Scope,
+			config:    resolveBeanConfig(bean, beans),
+		})
+	}
+	sort.Slice(handlers, func(i, j int) bool { return handlers[i].beanID < handlers[j].beanID })
+	return handlers, nil
+}
+
+// LinkHandlers links every cartridge to the handler bean for its template ID. It returns
+// the linked cartridges and the handlers that don't belong to any cartridge.
+func LinkHandlers(cartridges []Cartridge, handlers []Handler, DisableColor bool, Debug bool) ([]Cartridge, []Handler) {
+	var orphanHandlers []Handler
+	used := make(map[string]bool)
+
+	for index, cartridge := range cartridges {
+		var found bool
+		for _, handler := range handlers {
+			if handler.GetContentType() == cartridge.id {
+				found = true
+				used[handler.beanID] = true
+				linkedHandler := handler
+				cartridges[index].handler = &linkedHandler
+				utils.DisplayDebug("Cartridge "+cartridge.id+" is handled by "+handler.className, Debug, DisableColor)
+			}
+		}
+		if !found {
+			utils.DisplayWarning("No cartridge handler defined for cartridge "+cartridge.id, DisableColor)
+		}
+	}
+
+	for _, handler := range handlers {
+		if !used[handler.beanID] {
+			utils.DisplayWarning("Cartridge handler "+handler.beanID+" has no template", DisableColor)
+			orphanHandlers = append(orphanHandlers, handler)
+		}
+	}
+	return cartridges, orphanHandlers
+}
+
+// resolveBeanClass walks up the parent beans until a class is found
+func resolveBeanClass(bean springBean, beans map[string]springBean) string {
+	seen := make(map[string]bool)
+	for bean.Class == "" && bean.Parent != "" && !seen[bean.Parent] {
+		seen[bean.Parent] = true
+		parent, ok := beans[bean.Parent]
+		if !ok {
+			return ""
+		}
+		bean = parent
+	}
+	return bean.Class
+}
+
+// resolveBeanConfig collects the properties of a bean, properties of parent beans first
+func resolveBeanConfig(bean springBean, beans map[string]springBean) map[string]string {
+	var chain []springBean
+	seen := make(map[string]bool)
+	for {
+		chain = append([]springBean{bean}, chain...)
+		if bean.Parent == "" || seen[bean.Parent] {
+			break
+		}
+		seen[bean.Parent] = true
+		parent, ok := beans[bean.Parent]
+		if !ok {
+			break
+		}
+		bean = parent
+	}
+
+	config := make(map[string]string)
+	for _, chainBean := range chain {
+		for _, attr := range chainBean.Attrs {
+			if attr.Name.Space == springPNamespace {
+				config[strings.TrimSuffix(attr.Name.Local, "-ref")] = attr.Value
+			}
+		}
+		for _, property := range chainBean.Properties {
+			config[property.Name] = property.describe()
+		}
+	}
+	return config
+}
+
+// getID returns the ID of a bean, falling back to its first name
+func (b springBean) getID() string {
+	if b.ID != "" {
+		return b.ID
+	}
+	return strings.TrimSpace(strings.Split(b.Name, ",")[0])
+}
+
+// describe returns a printable value for a property
+func (p springProperty) describe() string {
+	switch {
+	case p.Value != "":
+		return p.Value
+	case p.Ref != "":
+		return "ref:" + p.Ref
+	case p.InRef.Bean != "":
+		return "ref:" + p.InRef.Bean
+	case p.Bean != nil:
+		return "bean:" + p.Bean.Class
+	case p.List != nil:
+		return "list of " + strconv.Itoa(len(p.List.Values)+len(p.List.Refs))
+	case p.Map != nil:
+		return "map of " + strconv.Itoa(len(p.Map.Entries))
+	}
+	return strings.TrimSpace(p.Inner.Value)
+}
+
+// GetBeanID returns the ID of the handler bean
+func (h Handler) GetBeanID() string {
+	return h.beanID
+}
+
+// GetContentType returns the content item type, i.e. the template ID, the handler is for
+func (h Handler) GetContentType() string {
+	return strings.TrimPrefix(h.beanID, handlerBeanPrefix)
+}
+
+// GetClassName returns the class of the handler
+func (h Handler) GetClassName() string {
+	return h.className
+}
+
+// GetScope returns the scope of the handler bean
+func (h Handler) GetScope() string {
+	return h.scope
+}
+
+// GetConfig returns the properties the handler is configured with
+func (h Handler) GetConfig() map[string]string {
+	return h.config
+}
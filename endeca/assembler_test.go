@@ -0,0 +1,60 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestMapAndLinkHandlers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cartridgemapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := dir + "/assembler-context.xml"
+	writeTestFile(t, configPath, `<beans xmlns:p="http://www.springframework.org/schema/p">
+  <bean id="NavigationCartridgeHandler" class="com.endeca.infront.cartridge.NavigationCartridgeHandler" scope="prototype">
+    <property name="navigationState" ref="navigationState"/>
+  </bean>
+  <bean id="CartridgeHandler_HeroBanner" class="com.acme.HeroBannerHandler" p:maxSlides="5"/>
+  <bean id="CartridgeHandler_ProductGrid" parent="NavigationCartridgeHandler">
+    <property name="recordsPerPage" value="12"/>
+  </bean>
+  <bean name="CartridgeHandler_Legacy, legacyHandler" class="com.acme.LegacyHandler"/>
+</beans>`)
+
+	handlers, err := MapHandlers(configPath, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handlers) != 3 {
+		t.Fatalf("MapHandlers() returned %d handlers, expected 3", len(handlers))
+	}
+	grid := handlers[2]
+	if grid.GetContentType() != "ProductGrid" || grid.GetClassName() != "com.endeca.infront.cartridge.NavigationCartridgeHandler" {
+		t.Errorf("MapHandlers() didn't resolve the parent bean: %+v", grid)
+	}
+	if config := grid.GetConfig(); config["navigationState"] != "ref:navigationState" || config["recordsPerPage"] != "12" {
+		t.Errorf("MapHandlers() returned config %v", config)
+	}
+	if config := handlers[0].GetConfig(); config["maxSlides"] != "5" {
+		t.Errorf("MapHandlers() didn't read p: attributes, got %v", config)
+	}
+
+	cartridges := []Cartridge{{id: "HeroBanner"}, {id: "ProductGrid"}, {id: "Unhandled"}}
+	linked, orphans := LinkHandlers(cartridges, handlers, true, false)
+	if handler := linked[0].GetHandler(); handler == nil || handler.GetClassName() != "com.acme.HeroBannerHandler" {
+		t.Errorf("HeroBanner is handled by %+v", handler)
+	}
+	if handler := linked[1].GetHandler(); handler == nil || handler.GetBeanID() != "CartridgeHandler_ProductGrid" {
+		t.Errorf("ProductGrid is handled by %+v", handler)
+	}
+	if handler := linked[2].GetHandler(); handler != nil {
+		t.Errorf("Unhandled is handled by %+v", handler)
+	}
+	if len(orphans) != 1 || orphans[0].GetBeanID() != "CartridgeHandler_Legacy" || orphans[0].GetContentType() != "Legacy" {
+		t.Errorf("LinkHandlers() returned orphans %+v", orphans)
+	}
+}
@@ -0,0 +1,87 @@
+package endeca
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+)
+
+// baselineVersion is the version of the baseline file format
+const baselineVersion = 1
+
+// Baseline is a set of accepted findings, stored in a baseline file
+type Baseline struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is an accepted finding. Only the fingerprint is used for matching, the
+// other fields are there so the baseline file can be reviewed.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	RuleID      string `json:"ruleId"`
+	Path        string `json:"path"`
+	Message     string `json:"message"`
+}
+
+// Fingerprint identifies a finding independent of the line it is on, so findings stay
+// matched to the baseline when lines are added above them
+func Fingerprint(finding Finding) string {
+	sum := sha256.Sum256([]byte(finding.ruleID + "\x00" + finding.path + "\x00" + finding.subject))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewBaseline creates a baseline accepting all given findings
+func NewBaseline(findings []Finding) Baseline {
+	baseline := Baseline{Version: baselineVersion, Findings: []BaselineEntry{}}
+	for _, finding := range findings {
+		baseline.Findings = append(baseline.Findings, BaselineEntry{
+			Fingerprint: Fingerprint(finding),
+			RuleID:      finding.ruleID,
+			Path:        finding.path,
+			Message:     finding.message,
+		})
+	}
+	return baseline
+}
+
+// ReadBaseline reads a baseline file
+func ReadBaseline(path string) (Baseline, error) {
+	var baseline Baseline
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return baseline, err
+	}
+	err = json.Unmarshal(b, &baseline)
+	return baseline, err
+}
+
+// WriteBaseline writes a baseline file
+func WriteBaseline(path string, baseline Baseline) error {
+	b, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// NewFindings returns the findings that aren't accepted by the baseline. A fingerprint in
+// the baseline accepts as many findings as it is listed for, so a second occurrence of the
+// same finding is still reported as new.
+func (b Baseline) NewFindings(findings []Finding) []Finding {
+	accepted := make(map[string]int)
+	for _, entry := range b.Findings {
+		accepted[entry.Fingerprint]++
+	}
+	var newFindings []Finding
+	for _, finding := range findings {
+		fingerprint := Fingerprint(finding)
+		if accepted[fingerprint] > 0 {
+			accepted[fingerprint]--
+			continue
+		}
+		newFindings = append(newFindings, finding)
+	}
+	return newFindings
+}
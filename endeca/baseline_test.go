@@ -0,0 +1,22 @@
+package endeca
+
+import (
+	"testing"
+)
+
+func TestBaselineNewFindings(t *testing.T) {
+	accepted := newFinding("dangling-template-reference", "OneColumn", "Template OneColumn doesn't exist", "pages/Default/home/content.xml", 3)
+	baseline := NewBaseline([]Finding{accepted})
+
+	moved := accepted
+	moved.line = 12
+	added := newFinding("dangling-template-reference", "TwoColumn", "Template TwoColumn doesn't exist", "pages/Default/home/content.xml", 3)
+
+	newFindings := baseline.NewFindings([]Finding{moved, added, accepted})
+	if len(newFindings) != 2 {
+		t.Fatalf("NewFindings() returned %d findings, expected 2: %+v", len(newFindings), newFindings)
+	}
+	if newFindings[0].GetSubject() != "TwoColumn" || newFindings[1].GetSubject() != "OneColumn" {
+		t.Errorf("NewFindings() returned wrong findings %+v", newFindings)
+	}
+}
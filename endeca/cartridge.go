@@ -7,17 +7,23 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/johnroach/cartridgemapper/utils"
-	"github.com/magiconair/properties"
 )
 
 // Cartridge is a combination of all relevant data for a cartridge
 type Cartridge struct {
 	// path is for path to cartridge
 	path string
+	// templateType is the type of the template, the section it can be placed in
+	templateType string
+	// properties defined in the template
+	properties []Property
 	// description for given cartridge
 	description string
+	// descriptions by locale, for templates with a localized description
+	descriptions map[string]string
 	// template id for cartridge
 	id string
 	// sites in which the cartridge is used
@@ -26,6 +32,10 @@ type Cartridge struct {
 	pages []string
 	// rules in which the cartridge is used
 	rules []string
+	// handler is the cartridge handler bean, nil when not linked to one
+	handler *Handler
+	// renderers are the storefront views rendering the cartridge
+	renderers []string
 }
 
 //Rules is a cartridgeID and rules definition
@@ -37,6 +47,7 @@ type Rules struct {
 // SharedContent is a generic struct used for XML walking
 type SharedContent struct {
 	XMLName       xml.Name
+	Attrs         []xml.Attr      `xml:",any,attr"`
 	ContentItem   []byte          `xml:",innerxml"`
 	SharedContent []SharedContent `xml:",any"`
 }
@@ -44,9 +55,28 @@ type SharedContent struct {
 // ContentTemplate is a cartridge definition defined in a template
 type ContentTemplate struct {
 	ID          string `xml:"id,attr"`
+	Type        string `xml:"type,attr"`
 	Description string `xml:"Description"`
+	ContentItem struct {
+		Properties []TemplateProperty `xml:"Property"`
+	} `xml:"ContentItem"`
 }
 
+// TemplateProperty is a property defined in the content item of a template
+type TemplateProperty struct {
+	Name  string          `xml:"name,attr"`
+	Value []SharedContent `xml:",any"`
+}
+
+// Property is a property of a cartridge and the type of value it holds
+type Property struct {
+	name      string
+	valueType string
+}
+
+// Workers is the number of templates MapCartridges scans at the same time
+var Workers = 1
+
 // MapCartridges allows one to map all cartridges and usages for a given base path
 func MapCartridges(basePath string, DisableColor bool, Debug bool) []Cartridge {
 	var cartridges []Cartridge
@@ -56,45 +86,85 @@ func MapCartridges(basePath string, DisableColor bool, Debug bool) []Cartridge {
 
 	endecaRules = getTemplateRules(basePath, DisableColor, Debug)
 
-	for _, cartridge := range cartridgeList {
-		// Should be getting descriptions from XML first than accordingly from property files
-
-		templateID, templateDescription, err := getTemplateData(cartridge, basePath+"/templates", DisableColor, Debug)
-		if err != nil {
-			utils.DisplayError("Couldn't read cartridge "+cartridge, err, DisableColor)
-		} else {
+	templateProgress := utils.StartProgress("templates", len(cartridgeList))
+	defer templateProgress.Finish()
+
+	// templates are scanned by Workers goroutines, the results keep the order of cartridgeList
+	results := make([]*Cartridge, len(cartridgeList))
+	workers := make(chan struct{}, maxInt(Workers, 1))
+	var wg sync.WaitGroup
+	for index, cartridge := range cartridgeList {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(index int, cartridge string) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			defer templateProgress.Increment()
+			// Should be getting descriptions from XML first than accordingly from property files
+
+			newCartridge, err := getTemplateData(cartridge, basePath+"/templates", DisableColor, Debug)
+			if err != nil {
+				utils.DisplayError("Couldn't read cartridge "+cartridge, err, DisableColor)
+				parseFailed()
+				return
+			}
 			var cartridgeRules []string
 			for _, endecaRule := range endecaRules {
 				if endecaRule.cartridgeID == cartridge {
 					cartridgeRules = endecaRule.rules
 				}
 			}
-			var newCartridge = Cartridge{
-				id:          templateID,
-				description: templateDescription,
-				rules:       cartridgeRules,
-			}
+			newCartridge.rules = cartridgeRules
 			newCartridge = getCartridgeSitePageUsage(basePath, newCartridge, DisableColor, Debug)
-			cartridges = append(cartridges, newCartridge)
+			results[index] = &newCartridge
+		}(index, cartridge)
+	}
+	wg.Wait()
+
+	for _, cartridge := range results {
+		if cartridge != nil {
+			cartridges = append(cartridges, *cartridge)
 		}
 	}
 	return cartridges
 }
 
-func getDescriptionFromProperty(path string, DisableColor bool, Debug bool) string {
-	var description string
-	p, err := properties.LoadFile(path+"/locales/Resources_en.properties", properties.UTF8)
-	if err == nil {
-		description = p.GetString("template.description", "")
-		if description == "" {
-			utils.DisplayError("Description doesn't exist for template in "+path, nil, DisableColor)
-			return "No description specified."
+// maxInt returns the larger of two ints
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// getDescriptionFromProperties resolves the placeholders of a description from the locale
+// files of a template. It returns the description in the default locale along with the
+// description in every locale of the template it can be resolved in.
+func getDescriptionFromProperties(path string, description string, DisableColor bool, Debug bool) (string, map[string]string) {
+	descriptions := make(map[string]string)
+	bundles, err := loadLocaleBundles(path)
+	if err != nil {
+		utils.WithFields(utils.Fields{"file": path + "/locales"}).Error("Couldn't read locale files for description of template", err)
+		parseFailed()
+		return "No description specified.", descriptions
+	}
+	if len(bundles) == 0 {
+		utils.WithFields(utils.Fields{"file": path + "/locales"}).Warn("locale file for description doesn't exist for template")
+		return "No description specified.", descriptions
+	}
+	for _, locale := range bundles.locales() {
+		if localized, missing := bundles.resolve(locale, description); len(missing) == 0 && strings.TrimSpace(localized) != "" {
+			descriptions[locale] = localized
+		} else {
+			utils.WithFields(utils.Fields{"file": path + "/locales", "locale": locale}).Debug("Description placeholders " + strings.Join(missing, ", ") + " don't resolve")
 		}
-	} else {
-		utils.DisplayError("locale file for description doesn't exist for template in "+path, err, DisableColor)
-		return "No description specified."
 	}
-	return description
+	localized, missing := bundles.resolve(defaultLocale(), description)
+	if len(missing) > 0 || strings.TrimSpace(localized) == "" {
+		utils.WithFields(utils.Fields{"file": path + "/locales", "locale": defaultLocale()}).Warn("Description doesn't exist for template")
+		return "No description specified.", descriptions
+	}
+	return localized, descriptions
 }
 
 // getCartridgePaths gets the cartridge paths
@@ -109,7 +179,7 @@ func getCartridgePaths(path string, DisableColor bool, Debug bool) []string {
 	for _, file := range files {
 		fileInfo, err := os.Stat(file)
 		if err == nil {
-			if fileInfo.IsDir() {
+			if fileInfo.IsDir() && !isIgnored(filepath.Dir(path), file) {
 				_, cartridgeName := filepath.Split(file)
 				cartridgePaths = append(cartridgePaths, cartridgeName)
 			}
@@ -124,11 +194,55 @@ func getCartridgePaths(path string, DisableColor bool, Debug bool) []string {
 func getCartridgeSitePageUsage(basePath string, cartridge Cartridge, DisableColor bool, Debug bool) Cartridge {
 	var endecaSitePath = basePath + "/pages"
 	utils.DisplayDebug("Starting Endeca template site and page usage scan for "+cartridge.id, Debug, DisableColor)
-	err := filepath.Walk(endecaSitePath, func(path string, f os.FileInfo, walkError error) error {
-		if strings.Contains(path, "content.xml") {
+	err := scanContentFiles(basePath, endecaSitePath, "site and page scan", utils.Fields{"cartridge": cartridge.id}, func(path string, n SharedContent) {
+		siteName, pageName := sitePagePath(endecaSitePath, path)
+		walk([]SharedContent{n}, func(n SharedContent) bool {
+			if n.XMLName.Local == "TemplateId" {
+				cartridgeName := string(n.ContentItem)
+				if cartridgeName == cartridge.id {
+					utils.DisplayDebug("Found template in "+path+" which means it was in site "+siteName, Debug, DisableColor)
+					cartridge = cartridge.addSite(siteName)
+					cartridge = cartridge.addPage(pageName)
+				}
+			}
+			if n.XMLName.Local == "String" {
+				var stringValue = string(n.ContentItem)
+				var oldCartridgeRules = cartridge.rules
+				for _, oldCartridgeEndecaRule := range oldCartridgeRules {
+					if "/content/"+oldCartridgeEndecaRule == stringValue {
+						cartridge = cartridge.addSite(siteName)
+						cartridge = cartridge.addPage(pageName)
+					}
+				}
+
+			}
+			return true
+		})
+	})
+
+	if err != nil {
+		utils.DisplayError("Could not walk through site path", err, DisableColor)
+	}
+	return cartridge
+}
+
+// scanContentFiles decodes every content.xml under a directory that isn't ignored and
+// passes it to scan. Files that can't be read or parsed are logged with the name of the
+// scan and the fields and skipped.
+func scanContentFiles(basePath string, directory string, scanName string, fields utils.Fields, scan func(path string, n SharedContent)) error {
+	return filepath.Walk(directory, func(path string, f os.FileInfo, walkError error) error {
+		if skip, skipError := skipIgnored(basePath, path, f); skip {
+			return skipError
+		}
+		if isContentFile(f) {
+			fileFields := utils.Fields{"file": path}
+			for key, value := range fields {
+				fileFields[key] = value
+			}
 			xmlFile, xmlErr := os.Open(path)
 			if xmlErr != nil {
-				utils.DisplayError("Couldn't read XML for site and page scan at path "+path, xmlErr, DisableColor)
+				utils.WithFields(fileFields).Error("Couldn't read XML for "+scanName, xmlErr)
+				parseFailed()
 			} else {
 				b, _ := ioutil.ReadAll(xmlFile)
 				buf := bytes.NewBuffer(b)
@@ -136,56 +250,35 @@ func getCartridgeSitePageUsage(basePath string, cartridge Cartridge, DisableColo
 				var n SharedContent
 				xmlReadErr := dec.Decode(&n)
 				if xmlReadErr != nil {
-					panic(xmlReadErr)
+					utils.WithFields(fileFields).Error("Couldn't parse XML for "+scanName, xmlReadErr)
+					parseFailed()
+				} else {
+					scan(path, n)
 				}
-				walk([]SharedContent{n}, func(n SharedContent) bool {
-					var pathInfo = strings.Split(path, "/")
-					var siteName = pathInfo[2]
-					var pageName = strings.Join(pathInfo[3:len(pathInfo)-1], "/")
-
-					if n.XMLName.Local == "TemplateId" {
-						cartridgeName := string(n.ContentItem)
-						if cartridgeName == cartridge.id {
-							utils.DisplayDebug("Found template in "+path+" which means it was in site "+siteName, Debug, DisableColor)
-							cartridge = cartridge.addSite(siteName)
-							cartridge = cartridge.addPage(pageName)
-						}
-					}
-					if n.XMLName.Local == "String" {
-						var stringValue = string(n.ContentItem)
-						var oldCartridgeRules = cartridge.rules
-						for _, oldCartridgeEndecaRule := range oldCartridgeRules {
-							if "/content/"+oldCartridgeEndecaRule == stringValue {
-								cartridge = cartridge.addSite(siteName)
-								cartridge = cartridge.addPage(pageName)
-							}
-						}
-
-					}
-					return true
-				})
 			}
 			xmlFile.Close()
 		}
 		return walkError
 	})
-
-	if err != nil {
-		utils.DisplayError("Could not walk through site path", err, DisableColor)
-	}
-	return cartridge
 }
 
 func getTemplateRules(basePath string, DisableColor bool, Debug bool) []Rules {
 	var endecaRules []Rules
 	var endecaRulesPath = basePath + "/content"
 	utils.DisplayInfo("Starting Endeca shared content scan.", DisableColor)
+	contentProgress := utils.StartProgress("content", countContentFiles(basePath, endecaRulesPath))
 	err := filepath.Walk(endecaRulesPath, func(path string, f os.FileInfo, err error) error {
-		if strings.Contains(path, "content.xml") {
+		if skip, skipError := skipIgnored(basePath, path, f); skip {
+			return skipError
+		}
+		if isContentFile(f) {
+			contentProgress.Increment()
+			utils.WithFields(utils.Fields{"file": path}).Trace("Scanning shared content")
 			xmlFile, xmlErr := os.Open(path)
 
 			if xmlErr != nil {
-				utils.DisplayError("Error opening file: "+path, xmlErr, DisableColor)
+				utils.WithFields(utils.Fields{"file": path}).Error("Error opening file", xmlErr)
+				parseFailed()
 			} else {
 				b, _ := ioutil.ReadAll(xmlFile)
 				buf := bytes.NewBuffer(b)
@@ -193,15 +286,17 @@ func getTemplateRules(basePath string, DisableColor bool, Debug bool) []Rules {
 				var n SharedContent
 				xmlReadErr := dec.Decode(&n)
 				if xmlReadErr != nil {
-					panic(xmlReadErr)
+					utils.WithFields(utils.Fields{"file": path}).Error("Couldn't parse XML file", xmlReadErr)
+					parseFailed()
+					xmlFile.Close()
+					return err
 				}
 
 				walk([]SharedContent{n}, func(n SharedContent) bool {
 					if n.XMLName.Local == "TemplateId" {
 						cartridgeName := string(n.ContentItem)
 						var found bool
-						var endecaRulePath = strings.Replace(path, ".remove_me/content/", "", -1)
-						endecaRulePath = strings.Replace(endecaRulePath, "/content.xml", "", -1)
+						var endecaRulePath = relativePath(endecaRulesPath, filepath.Dir(path))
 						for index, endecaRule := range endecaRules {
 							if endecaRule.cartridgeID == cartridgeName {
 								found = true
@@ -228,6 +323,7 @@ func getTemplateRules(basePath string, DisableColor bool, Debug bool) []Rules {
 		}
 		return err
 	})
+	contentProgress.Finish()
 	if err != nil {
 		utils.DisplayError("Could not scan content directory.", err, DisableColor)
 	}
@@ -243,7 +339,7 @@ func walk(nodes []SharedContent, f func(SharedContent) bool) {
 	}
 }
 
-func getTemplateData(templateName string, basePath string, DisableColor bool, Debug bool) (string, string, error) {
+func getTemplateData(templateName string, basePath string, DisableColor bool, Debug bool) (Cartridge, error) {
 	var templateDescription string
 	var templateID string
 	var templateError error
@@ -252,14 +348,17 @@ func getTemplateData(templateName string, basePath string, DisableColor bool, De
 	xmlFile, err := os.Open(basePath + "/" + templateName + "/template.xml")
 	if err != nil {
 		utils.DisplayError("Error opening file:", err, DisableColor)
-		return templateName, "No description.", err
+		return Cartridge{id: templateName, description: "No description."}, err
 	}
 	defer xmlFile.Close()
 
 	b, _ := ioutil.ReadAll(xmlFile)
 
 	var contentTemplate ContentTemplate
-	xml.Unmarshal(b, &contentTemplate)
+	if xmlErr := xml.Unmarshal(b, &contentTemplate); xmlErr != nil {
+		utils.WithFields(utils.Fields{"file": basePath + "/" + templateName + "/template.xml"}).Error("Couldn't parse template XML", xmlErr)
+		parseFailed()
+	}
 
 	if contentTemplate.ID == "" {
 		templateID = templateName
@@ -268,8 +367,9 @@ func getTemplateData(templateName string, basePath string, DisableColor bool, De
 		templateID = contentTemplate.ID
 	}
 
-	if contentTemplate.Description == "${template.description}" {
-		templateDescription = getDescriptionFromProperty(basePath+"/"+templateName, DisableColor, Debug)
+	var descriptions map[string]string
+	if hasPlaceholders(contentTemplate.Description) {
+		templateDescription, descriptions = getDescriptionFromProperties(basePath+"/"+templateName, strings.TrimSpace(contentTemplate.Description), DisableColor, Debug)
 	} else if strings.TrimSpace(contentTemplate.Description) == "" {
 		templateDescription = "No description provided."
 		utils.DisplayWarning("Cartridge definition not defined in template. Cartridge name: "+templateName, DisableColor)
@@ -277,7 +377,23 @@ func getTemplateData(templateName string, basePath string, DisableColor bool, De
 		templateDescription = contentTemplate.Description
 	}
 
-	return templateID, templateDescription, templateError
+	var properties []Property
+	for _, templateProperty := range contentTemplate.ContentItem.Properties {
+		var property = Property{name: templateProperty.Name}
+		if len(templateProperty.Value) > 0 {
+			property.valueType = templateProperty.Value[0].XMLName.Local
+		}
+		properties = append(properties, property)
+	}
+
+	return Cartridge{
+		path:         templateName,
+		id:           templateID,
+		description:  templateDescription,
+		descriptions: descriptions,
+		templateType: contentTemplate.Type,
+		properties:   properties,
+	}, templateError
 }
 
 // GetID returns the ID of the cartridge
@@ -290,6 +406,11 @@ func (f Cartridge) GetDescription() string {
 	return f.description
 }
 
+// GetDescriptions returns the descriptions by locale, empty when the description isn't localized
+func (f Cartridge) GetDescriptions() map[string]string {
+	return f.descriptions
+}
+
 // GetPages returns the pages for a given cartridge
 func (f Cartridge) GetPages() []string {
 	return f.pages
@@ -310,6 +431,36 @@ func (f Cartridge) GetRules() []string {
 	return f.rules
 }
 
+// GetTemplateType returns the type of the template of the cartridge
+func (f Cartridge) GetTemplateType() string {
+	return f.templateType
+}
+
+// GetProperties returns the properties defined in the template of the cartridge
+func (f Cartridge) GetProperties() []Property {
+	return f.properties
+}
+
+// GetName returns the name of the property
+func (p Property) GetName() string {
+	return p.name
+}
+
+// GetType returns the type of value the property holds, e.g. String or ContentItemList
+func (p Property) GetType() string {
+	return p.valueType
+}
+
+// GetHandler returns the cartridge handler of a cartridge, nil if it has none
+func (f Cartridge) GetHandler() *Handler {
+	return f.handler
+}
+
+// GetRenderers returns the storefront renderers of a cartridge
+func (f Cartridge) GetRenderers() []string {
+	return f.renderers
+}
+
 func (f Cartridge) addPage(page string) Cartridge {
 	var foundPage bool
 	for _, oldCartridgePage := range f.pages {
@@ -0,0 +1,29 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestGetTemplateRulesReadsOnlyContentFiles(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "cartridgemapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(basePath)
+
+	writeTestFile(t, basePath+"/content/Shared/HeroRule/content.xml",
+		`<ContentItem><TemplateId>HeroBanner</TemplateId></ContentItem>`)
+	writeTestFile(t, basePath+"/content/Shared/HeroRule/content.xml.bak",
+		`<ContentItem><TemplateId>OldBanner</TemplateId></ContentItem>`)
+	writeTestFile(t, basePath+"/content/Shared/old-content.xml/notes.txt", "not content")
+
+	if count := countContentFiles(basePath, basePath+"/content"); count != 1 {
+		t.Errorf("countContentFiles() = %d, expected 1", count)
+	}
+	rules := getTemplateRules(basePath, true, false)
+	if len(rules) != 1 || rules[0].cartridgeID != "HeroBanner" {
+		t.Errorf("getTemplateRules() = %+v, expected only the rule of HeroBanner", rules)
+	}
+}
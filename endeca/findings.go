@@ -0,0 +1,308 @@
+package endeca
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// Severities of findings, named after the SARIF result levels
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNote    = "note"
+	// SeverityNone turns a kind of finding off
+	SeverityNone = "none"
+)
+
+// FindingRule describes a kind of finding
+type FindingRule struct {
+	ID          string
+	Description string
+	Severity    string
+}
+
+// FindingRules are all kinds of findings Validate can report
+var FindingRules = []FindingRule{
+	{"xml-error", "XML file can't be read or parsed", SeverityError},
+	{"missing-description", "Template has no description", SeverityWarning},
+	{"missing-template-id", "Template has no id attribute, the directory name is used as cartridge ID", SeverityNote},
+	{"unused-cartridge", "Cartridge is not used on any page or content rule", SeverityWarning},
+	{"dangling-template-reference", "Content references a template that doesn't exist", SeverityError},
+	{"dangling-content-reference", "Page references a content rule that doesn't exist", SeverityError},
+}
+
+// SetSeverities overrides the severity of kinds of findings, by rule ID. Nothing is changed
+// when a rule or severity is unknown.
+func SetSeverities(severities map[string]string) error {
+	for ruleID, severity := range severities {
+		if err := checkSeverity(ruleID, severity); err != nil {
+			return err
+		}
+	}
+	for index, rule := range FindingRules {
+		if severity, ok := severities[rule.ID]; ok {
+			FindingRules[index].Severity = severity
+		}
+	}
+	return nil
+}
+
+// checkSeverity checks that a rule exists and a severity can be set for it
+func checkSeverity(ruleID string, severity string) error {
+	switch severity {
+	case SeverityError, SeverityWarning, SeverityNote, SeverityNone:
+	default:
+		return errors.New("unknown severity " + severity + " for " + ruleID + ", use error, warning, note or none")
+	}
+	for _, rule := range FindingRules {
+		if rule.ID == ruleID {
+			return nil
+		}
+	}
+	return errors.New("unknown finding rule " + ruleID)
+}
+
+// Finding is a problem found while validating an application
+type Finding struct {
+	// ruleID is the kind of finding, see FindingRules
+	ruleID string
+	// severity of the finding
+	severity string
+	// subject is the template, cartridge or reference the finding is about
+	subject string
+	// message describing the finding
+	message string
+	// path of the file the finding is in, relative to the application
+	path string
+	// line the finding is on, 0 when it isn't known
+	line int
+}
+
+// reference is a template or content rule reference found in a content.xml
+type reference struct {
+	element string
+	value   string
+	line    int
+}
+
+// Validate checks the templates, content and pages of an application for problems
+func Validate(basePath string, app Application, DisableColor bool, Debug bool) []Finding {
+	var findings []Finding
+	templateIDs := make(map[string]bool)
+	for _, cartridge := range app.Cartridges {
+		templateIDs[cartridge.id] = true
+	}
+
+	utils.DisplayInfo("Starting Endeca application validation.", DisableColor)
+	for _, templateName := range getCartridgePaths(basePath+"/templates", DisableColor, Debug) {
+		findings = append(findings, validateTemplate(basePath, templateName)...)
+	}
+
+	for _, cartridge := range app.Cartridges {
+		if len(cartridge.rules) == 0 && len(app.PagesUsing(cartridge.id)) == 0 {
+			findings = append(findings, newFinding("unused-cartridge", cartridge.id, "Cartridge "+cartridge.id+" is not used on any page or content rule",
+				"templates/"+cartridge.path+"/template.xml", 1))
+		}
+	}
+
+	for _, directory := range []string{"content", "pages"} {
+		err := filepath.Walk(basePath+"/"+directory, func(path string, f os.FileInfo, walkError error) error {
+			if skip, skipError := skipIgnored(basePath, path, f); skip {
+				return skipError
+			}
+			if walkError != nil || !isContentFile(f) {
+				return walkError
+			}
+			relPath := relativePath(basePath, path)
+			references, err := scanReferences(path)
+			if err != nil {
+				return appendXMLError(&findings, relPath, err)
+			}
+			for _, ref := range references {
+				switch {
+				case ref.element == "TemplateId" && !templateIDs[ref.value]:
+					findings = append(findings, newFinding("dangling-template-reference", ref.value,
+						"Template "+ref.value+" doesn't exist", relPath, ref.line))
+				case ref.element == "String" && !fileExists(basePath+"/content/"+strings.TrimPrefix(ref.value, contentReferencePrefix)+"/content.xml"):
+					findings = append(findings, newFinding("dangling-content-reference", ref.value,
+						"Content rule "+ref.value+" doesn't exist", relPath, ref.line))
+				}
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			utils.DisplayError("Could not validate "+directory+" directory.", err, DisableColor)
+		}
+	}
+
+	var reported []Finding
+	for _, finding := range findings {
+		if finding.severity != SeverityNone {
+			reported = append(reported, finding)
+		}
+	}
+	findings = reported
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].path == findings[j].path {
+			return findings[i].line < findings[j].line
+		}
+		return findings[i].path < findings[j].path
+	})
+	utils.DisplayInfo("Finished validation with "+strconv.Itoa(len(findings))+" findings.", DisableColor)
+	return findings
+}
+
+// validateTemplate checks a template.xml and its description
+func validateTemplate(basePath string, templateName string) []Finding {
+	var findings []Finding
+	relPath := "templates/" + templateName + "/template.xml"
+
+	b, err := ioutil.ReadFile(basePath + "/" + relPath)
+	if err != nil {
+		appendXMLError(&findings, relPath, err)
+		return findings
+	}
+	var contentTemplate ContentTemplate
+	if err := xml.Unmarshal(b, &contentTemplate); err != nil {
+		appendXMLError(&findings, relPath, err)
+		return findings
+	}
+
+	if contentTemplate.ID == "" {
+		findings = append(findings, newFinding("missing-template-id", templateName,
+			"Cartridge ID not defined in template "+templateName, relPath, lineOf(b, "<ContentTemplate")))
+	}
+
+	description := strings.TrimSpace(contentTemplate.Description)
+	if description == "" {
+		findings = append(findings, newFinding("missing-description", templateName,
+			"Cartridge definition not defined in template "+templateName, relPath, lineOf(b, "<ContentTemplate")))
+	} else if hasPlaceholders(description) {
+		localePath := "templates/" + templateName + "/locales/Resources_" + defaultLocale() + ".properties"
+		bundles, err := loadLocaleBundles(basePath + "/templates/" + templateName)
+		if err != nil || len(bundles) == 0 {
+			findings = append(findings, newFinding("missing-description", templateName,
+				"Locale file for description doesn't exist for template "+templateName, relPath, lineOf(b, "<Description")))
+		} else if localized, missing := bundles.resolve(defaultLocale(), description); len(missing) > 0 || strings.TrimSpace(localized) == "" {
+			findings = append(findings, newFinding("missing-description", templateName,
+				"Description doesn't exist for template "+templateName, localePath, 0))
+		}
+	}
+	return findings
+}
+
+// scanReferences reads the template and content rule references out of a content.xml
+// along with the line they are on
+func scanReferences(path string) ([]reference, error) {
+	var references []reference
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return references, err
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	var element string
+	var line int
+	for {
+		offset := dec.InputOffset()
+		token, err := dec.Token()
+		if err == io.EOF {
+			return references, nil
+		}
+		if err != nil {
+			return references, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			element = t.Name.Local
+			line = bytes.Count(b[:offset], []byte("\n")) + 1
+		case xml.EndElement:
+			element = ""
+		case xml.CharData:
+			value := strings.TrimSpace(string(t))
+			if element == "TemplateId" && value != "" {
+				references = append(references, reference{element: element, value: value, line: line})
+			}
+			if element == "String" && strings.HasPrefix(value, contentReferencePrefix) {
+				references = append(references, reference{element: element, value: value, line: line})
+			}
+		}
+	}
+}
+
+// appendXMLError adds an xml-error finding for err, using the line of syntax errors
+func appendXMLError(findings *[]Finding, relPath string, err error) error {
+	var line int
+	if syntaxError, ok := err.(*xml.SyntaxError); ok {
+		line = syntaxError.Line
+	}
+	*findings = append(*findings, newFinding("xml-error", "", err.Error(), relPath, line))
+	return nil
+}
+
+// newFinding creates a finding with the severity of its rule
+func newFinding(ruleID string, subject string, message string, path string, line int) Finding {
+	var finding = Finding{ruleID: ruleID, subject: subject, message: message, path: path, line: line}
+	for _, rule := range FindingRules {
+		if rule.ID == ruleID {
+			finding.severity = rule.Severity
+		}
+	}
+	return finding
+}
+
+// lineOf returns the line the first occurrence of needle is on, 0 if it isn't found
+func lineOf(b []byte, needle string) int {
+	index := bytes.Index(b, []byte(needle))
+	if index < 0 {
+		return 0
+	}
+	return bytes.Count(b[:index], []byte("\n")) + 1
+}
+
+// fileExists checks if a file exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// GetRuleID returns the kind of finding, see FindingRules
+func (f Finding) GetRuleID() string {
+	return f.ruleID
+}
+
+// GetSeverity returns the severity of the finding
+func (f Finding) GetSeverity() string {
+	return f.severity
+}
+
+// GetMessage returns the message describing the finding
+func (f Finding) GetMessage() string {
+	return f.message
+}
+
+// GetPath returns the file the finding is in, relative to the application
+func (f Finding) GetPath() string {
+	return f.path
+}
+
+// GetLine returns the line the finding is on, 0 when it isn't known
+func (f Finding) GetLine() int {
+	return f.line
+}
+
+// GetSubject returns the template, cartridge or reference the finding is about
+func (f Finding) GetSubject() string {
+	return f.subject
+}
@@ -0,0 +1,46 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "cartridgemapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(basePath)
+
+	writeTestFile(t, basePath+"/templates/Grid/template.xml",
+		`<ContentTemplate type="MainContent" id="Grid"><Description>Grid</Description></ContentTemplate>`)
+	writeTestFile(t, basePath+"/templates/Unused/template.xml",
+		"<ContentTemplate type=\"MainContent\">\n</ContentTemplate>")
+	writeTestFile(t, basePath+"/pages/Default/home/content.xml",
+		"<ContentItem>\n<TemplateId>Grid</TemplateId>\n<TemplateId>Missing</TemplateId>\n<String>/content/Gone</String>\n</ContentItem>")
+	writeTestFile(t, basePath+"/content/Broken/content.xml", "<ContentItem>\n<TemplateId>")
+
+	findings := Validate(basePath, MapApplication(basePath, true, false), true, false)
+	expected := []struct {
+		ruleID string
+		path   string
+		line   int
+	}{
+		{"xml-error", "content/Broken/content.xml", 2},
+		{"dangling-template-reference", "pages/Default/home/content.xml", 3},
+		{"dangling-content-reference", "pages/Default/home/content.xml", 4},
+		{"missing-template-id", "templates/Unused/template.xml", 1},
+		{"missing-description", "templates/Unused/template.xml", 1},
+		{"unused-cartridge", "templates/Unused/template.xml", 1},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("Validate() returned %d findings, expected %d: %+v", len(findings), len(expected), findings)
+	}
+	for index, finding := range findings {
+		if finding.GetRuleID() != expected[index].ruleID || finding.GetPath() != expected[index].path || finding.GetLine() != expected[index].line {
+			t.Errorf("Validate() finding %d is %s %s:%d, expected %+v", index,
+				finding.GetRuleID(), finding.GetPath(), finding.GetLine(), expected[index])
+		}
+	}
+}
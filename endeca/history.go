@@ -0,0 +1,143 @@
+package endeca
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// snapshotVersion is the version of the snapshot file format
+const snapshotVersion = 1
+
+// snapshotTimeFormat is the time format of snapshot IDs, sortable like the times
+const snapshotTimeFormat = "20060102T150405Z"
+
+// Snapshot is the mapped model of a run, stored as a JSON file in a history directory.
+// The cartridge usage counts are kept next to the model so trends don't need to decode it.
+type Snapshot struct {
+	Version int       `json:"version"`
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Label   string    `json:"label,omitempty"`
+	// App is the name of the application, the name of its export
+	App   string           `json:"app"`
+	Usage []CartridgeUsage `json:"usage"`
+	// Model is the mapped application as JSON, see the MarshalJSON methods
+	Model json.RawMessage `json:"model,omitempty"`
+}
+
+// CartridgeUsage is how much a cartridge is used in a snapshot
+type CartridgeUsage struct {
+	ID    string `json:"id"`
+	Sites int    `json:"sites"`
+	Pages int    `json:"pages"`
+	Rules int    `json:"rules"`
+}
+
+// NewSnapshot creates a snapshot of a mapped application taken at the given time
+func NewSnapshot(app Application, name string, label string, now time.Time) (Snapshot, error) {
+	model, err := json.Marshal(app)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot := Snapshot{
+		Version: snapshotVersion,
+		ID:      now.UTC().Format(snapshotTimeFormat),
+		Time:    now.UTC(),
+		Label:   label,
+		App:     name,
+		Usage:   []CartridgeUsage{},
+		Model:   model,
+	}
+	for _, cartridge := range app.Cartridges {
+		snapshot.Usage = append(snapshot.Usage, CartridgeUsage{
+			ID:    cartridge.id,
+			Sites: len(cartridge.sites),
+			Pages: len(app.PagesUsing(cartridge.id)),
+			Rules: len(cartridge.rules),
+		})
+	}
+	return snapshot, nil
+}
+
+// SaveSnapshot stores a snapshot in a history directory, creating the directory, and returns
+// the path of its file. Snapshots taken in the same second get a number added to their ID.
+func SaveSnapshot(historyDir string, snapshot Snapshot) (string, error) {
+	if err := os.MkdirAll(historyDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	id := snapshot.ID
+	path := filepath.Join(historyDir, id+".json")
+	for number := 2; fileExists(path); number++ {
+		snapshot.ID = id + "-" + strconv.Itoa(number)
+		path = filepath.Join(historyDir, snapshot.ID+".json")
+	}
+	b, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// ReadSnapshots reads the snapshots of a history directory, oldest first. The models are
+// left out unless withModel is set, they make up most of a snapshot.
+func ReadSnapshots(historyDir string, withModel bool) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	files, err := filepath.Glob(filepath.Join(historyDir, "*.json"))
+	if err != nil {
+		return snapshots, err
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return snapshots, err
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(b, &snapshot); err != nil {
+			return snapshots, &os.PathError{Op: "read snapshot", Path: file, Err: err}
+		}
+		if !withModel {
+			snapshot.Model = nil
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if snapshots[i].Time.Equal(snapshots[j].Time) {
+			return snapshots[i].ID < snapshots[j].ID
+		}
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// CartridgeTrend is the usage of a cartridge across snapshots
+type CartridgeTrend struct {
+	ID string
+	// Usage has an entry per snapshot, the zero usage when the cartridge wasn't in it
+	Usage []CartridgeUsage
+}
+
+// UsageTrends returns the usage of every cartridge of the snapshots across them, by ID
+func UsageTrends(snapshots []Snapshot) []CartridgeTrend {
+	trends := make(map[string]*CartridgeTrend)
+	for index, snapshot := range snapshots {
+		for _, usage := range snapshot.Usage {
+			trend, ok := trends[usage.ID]
+			if !ok {
+				trend = &CartridgeTrend{ID: usage.ID, Usage: make([]CartridgeUsage, len(snapshots))}
+				trends[usage.ID] = trend
+			}
+			trend.Usage[index] = usage
+		}
+	}
+	var trendList []CartridgeTrend
+	for _, trend := range trends {
+		trendList = append(trendList, *trend)
+	}
+	sort.Slice(trendList, func(i, j int) bool { return trendList[i].ID < trendList[j].ID })
+	return trendList
+}
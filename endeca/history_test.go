@@ -0,0 +1,54 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveAndReadSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	older := Snapshot{ID: "20180301T090000Z", Time: now.Add(-time.Hour), App: "app",
+		Usage: []CartridgeUsage{{ID: "Banner", Pages: 2}, {ID: "Unused"}}}
+	newer := Snapshot{ID: now.Format(snapshotTimeFormat), Time: now, App: "app", Label: "r2",
+		Usage: []CartridgeUsage{{ID: "Banner", Pages: 3}, {ID: "Grid", Pages: 1}}}
+	for _, snapshot := range []Snapshot{newer, newer, older} {
+		if _, err := SaveSnapshot(dir, snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "20180301T100000Z-2.json")); err != nil {
+		t.Errorf("expected a numbered snapshot for the same second: %v", err)
+	}
+
+	snapshots, err := ReadSnapshots(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, snapshot := range snapshots {
+		ids = append(ids, snapshot.ID)
+	}
+	if expected := []string{"20180301T090000Z", "20180301T100000Z", "20180301T100000Z-2"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("ReadSnapshots IDs = %q, expected %q", ids, expected)
+	}
+
+	trends := UsageTrends(snapshots[:2])
+	expected := []CartridgeTrend{
+		{ID: "Banner", Usage: []CartridgeUsage{{ID: "Banner", Pages: 2}, {ID: "Banner", Pages: 3}}},
+		{ID: "Grid", Usage: []CartridgeUsage{{}, {ID: "Grid", Pages: 1}}},
+		{ID: "Unused", Usage: []CartridgeUsage{{ID: "Unused"}, {}}},
+	}
+	if !reflect.DeepEqual(trends, expected) {
+		t.Errorf("UsageTrends = %+v, expected %+v", trends, expected)
+	}
+}
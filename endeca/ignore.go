@@ -0,0 +1,146 @@
+package endeca
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// IgnoreFile is the file ignore patterns are read from, one per line
+const IgnoreFile = ".cartridgemapperignore"
+
+// ignoreRules are the patterns of paths left out of the scans and the paths they matched
+var ignoreRules = struct {
+	sync.Mutex
+	patterns []string
+	ignored  map[string]bool
+}{ignored: make(map[string]bool)}
+
+// SetIgnorePatterns sets the patterns of template, content and page paths to leave out of
+// the scans. Patterns are globs relative to the root of the application, e.g.
+// templates/Sandbox* or pages/TestSite, and also leave out everything below a matching
+// directory. A pattern without a slash matches a file or directory name at any depth and
+// ** matches any number of directories.
+func SetIgnorePatterns(patterns []string) error {
+	var cleaned []string
+	for _, pattern := range patterns {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return errors.New("invalid ignore pattern " + pattern + ": " + err.Error())
+			}
+		}
+		cleaned = append(cleaned, pattern)
+	}
+
+	ignoreRules.Lock()
+	defer ignoreRules.Unlock()
+	ignoreRules.patterns = cleaned
+	ignoreRules.ignored = make(map[string]bool)
+	return nil
+}
+
+// ReadIgnoreFile reads the patterns of an ignore file, skipping blank lines and # comments
+func ReadIgnoreFile(path string) ([]string, error) {
+	var patterns []string
+	file, err := os.Open(path)
+	if err != nil {
+		return patterns, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// IgnoredCounts returns how many template directories and content and page paths were left
+// out of the scans so far. A directory counts once, not the files below it.
+func IgnoredCounts() (templates int, content int, pages int) {
+	ignoreRules.Lock()
+	defer ignoreRules.Unlock()
+	for relPath := range ignoreRules.ignored {
+		switch strings.SplitN(relPath, "/", 2)[0] {
+		case "templates":
+			templates++
+		case "content":
+			content++
+		case "pages":
+			pages++
+		}
+	}
+	return templates, content, pages
+}
+
+// isIgnored checks if a path of the application matches an ignore pattern and remembers it
+func isIgnored(basePath string, path string) bool {
+	relPath := relativePath(basePath, path)
+	ignoreRules.Lock()
+	defer ignoreRules.Unlock()
+	for _, pattern := range ignoreRules.patterns {
+		if matchIgnorePattern(pattern, relPath) {
+			ignoreRules.ignored[relPath] = true
+			return true
+		}
+	}
+	return false
+}
+
+// skipIgnored is used in walks of the application: it reports if a path is ignored along
+// with the error to return from the walk function, skipping ignored directories entirely
+func skipIgnored(basePath string, path string, f os.FileInfo) (bool, error) {
+	if f == nil || !isIgnored(basePath, path) {
+		return false, nil
+	}
+	if f.IsDir() {
+		return true, filepath.SkipDir
+	}
+	return true, nil
+}
+
+// matchIgnorePattern checks if a pattern matches a relative path or one of its parents
+func matchIgnorePattern(pattern string, relPath string) bool {
+	segments := strings.Split(relPath, "/")
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range segments {
+			if matched, _ := path.Match(pattern, segment); matched {
+				return true
+			}
+		}
+		return false
+	}
+	return matchSegments(strings.Split(pattern, "/"), segments)
+}
+
+// matchSegments matches pattern segments against the leading path segments, ** matching
+// any number of segments
+func matchSegments(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for index := 0; index <= len(segments); index++ {
+			if matchSegments(pattern[1:], segments[index:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	matched, _ := path.Match(pattern[0], segments[0])
+	return matched && matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,27 @@
+package endeca
+
+import (
+	"testing"
+)
+
+func TestMatchIgnorePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		relPath string
+		matched bool
+	}{
+		{"templates/Sandbox*", "templates/SandboxBanner", true},
+		{"templates/Sandbox*", "templates/Banner", false},
+		{"pages/TestSite", "pages/TestSite/home/content.xml", true},
+		{"pages/TestSite", "pages/TestSiteTwo/home/content.xml", false},
+		{"content/**/Test*", "content/Shared/Deep/TestRule/content.xml", true},
+		{"content/**/Test*", "content/TestRule", true},
+		{"sandbox", "pages/Default/sandbox/content.xml", true},
+		{"sandbox", "pages/Default/sandboxes", false},
+	}
+	for _, test := range tests {
+		if matched := matchIgnorePattern(test.pattern, test.relPath); matched != test.matched {
+			t.Errorf("matchIgnorePattern(%q, %q) = %v, expected %v", test.pattern, test.relPath, matched, test.matched)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package endeca
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// Kinds of impact references
+const (
+	// ImpactPage is a page placing the cartridge
+	ImpactPage = "page"
+	// ImpactRule is a content rule holding the cartridge
+	ImpactRule = "rule"
+	// ImpactPageRule is a page pulling in the cartridge through a content rule
+	ImpactPageRule = "page-rule"
+)
+
+// CartridgeImpact is everything that reaches a cartridge, the blast radius of changing it
+type CartridgeImpact struct {
+	ID string `json:"id"`
+	// Defined is whether the application has a template for the cartridge
+	Defined bool     `json:"defined"`
+	Sites   []string `json:"sites"`
+	// Pages are the keys of the pages using the cartridge, see Page.GetKey
+	Pages []string `json:"pages"`
+	Rules []string `json:"rules"`
+	// Slots are the slots the cartridge is placed in, as <parent template ID>.<slot>
+	Slots []string `json:"slots"`
+	// Parents are the template IDs of the content items the cartridge is placed in
+	Parents    []string          `json:"parents"`
+	References []ImpactReference `json:"references"`
+}
+
+// ImpactReference is a content.xml reaching a cartridge
+type ImpactReference struct {
+	// Kind is ImpactPage, ImpactRule or ImpactPageRule
+	Kind string `json:"kind"`
+	// File is the content.xml relative to the application
+	File string `json:"file"`
+	Site string `json:"site,omitempty"`
+	Page string `json:"page,omitempty"`
+	// Rule holding the cartridge, for ImpactRule and ImpactPageRule references
+	Rule string `json:"rule,omitempty"`
+	// Parent is the template ID of the content item the cartridge, or the rule reference
+	// for ImpactPageRule references, is placed in. Empty at the top of the file.
+	Parent string `json:"parent,omitempty"`
+	Slot   string `json:"slot,omitempty"`
+	// Name given to the content item
+	Name string `json:"name,omitempty"`
+}
+
+// MapImpact scans the content rules and pages of an application for every reference to a
+// cartridge, the same files the site and page usage scan reads. Pages reach the cartridge
+// directly or through a rule reference in one of their slots. The cartridge is defined when
+// the mapped application has a template with its ID, whatever the template directory is named.
+func MapImpact(basePath string, app Application, cartridgeID string, DisableColor bool, Debug bool) CartridgeImpact {
+	impact := CartridgeImpact{
+		ID:         cartridgeID,
+		Sites:      []string{},
+		Pages:      []string{},
+		Rules:      []string{},
+		Slots:      []string{},
+		Parents:    []string{},
+		References: []ImpactReference{},
+	}
+	_, impact.Defined = app.FindCartridge(cartridgeID)
+	fields := utils.Fields{"cartridge": cartridgeID}
+
+	var contentPath = basePath + "/content"
+	err := scanContentFiles(basePath, contentPath, "impact scan", fields, func(path string, n SharedContent) {
+		rulePath := relativePath(contentPath, filepath.Dir(path))
+		walkComponents(contentItems(n), "", func(component Component, parent string) {
+			if component.templateID == cartridgeID {
+				impact.addReference(ImpactReference{Kind: ImpactRule, File: relativePath(basePath, path),
+					Rule: rulePath, Parent: parent, Slot: component.slot, Name: component.name})
+			}
+		})
+	})
+	if err != nil {
+		utils.DisplayError("Could not scan content directory.", err, DisableColor)
+	}
+
+	var pagesPath = basePath + "/pages"
+	err = scanContentFiles(basePath, pagesPath, "impact scan", fields, func(path string, n SharedContent) {
+		siteName, pageName := sitePagePath(pagesPath, path)
+		if pageName == "" {
+			return
+		}
+		reference := ImpactReference{File: relativePath(basePath, path), Site: siteName, Page: pageName}
+		walkComponents(contentItems(n), "", func(component Component, parent string) {
+			reference.Parent, reference.Slot, reference.Name = parent, component.slot, component.name
+			if component.templateID == cartridgeID {
+				reference.Kind, reference.Rule = ImpactPage, ""
+				impact.addReference(reference)
+			} else if component.rule != "" && containsString(impact.Rules, component.rule) {
+				reference.Kind, reference.Rule = ImpactPageRule, component.rule
+				impact.addReference(reference)
+			}
+		})
+	})
+	if err != nil {
+		utils.DisplayError("Could not walk through site path", err, DisableColor)
+	}
+
+	for _, list := range [][]string{impact.Sites, impact.Pages, impact.Rules, impact.Slots, impact.Parents} {
+		sort.Strings(list)
+	}
+	return impact
+}
+
+// addReference adds a reference and what it reaches the cartridge through to the impact
+func (i *CartridgeImpact) addReference(reference ImpactReference) {
+	utils.WithFields(utils.Fields{"file": reference.File, "cartridge": i.ID}).Debug("Found " + reference.Kind + " reference")
+	i.References = append(i.References, reference)
+	if reference.Site != "" {
+		i.Sites = appendUnique(i.Sites, reference.Site)
+		i.Pages = appendUnique(i.Pages, Page{site: reference.Site, name: reference.Page}.GetKey())
+	}
+	if reference.Rule != "" {
+		i.Rules = appendUnique(i.Rules, reference.Rule)
+	}
+	if reference.Parent != "" {
+		i.Parents = appendUnique(i.Parents, reference.Parent)
+		i.Slots = appendUnique(i.Slots, reference.Parent+"."+reference.Slot)
+	}
+}
+
+// contentItems returns the outermost content items of a content.xml
+func contentItems(n SharedContent) []Component {
+	if n.XMLName.Local == "ContentItem" {
+		return []Component{buildComponent(n)}
+	}
+	var components []Component
+	for _, child := range n.SharedContent {
+		components = append(components, contentItems(child)...)
+	}
+	return components
+}
+
+// walkComponents calls visit for every component of the trees with the template ID of the
+// content item it is placed in
+func walkComponents(components []Component, parent string, visit func(component Component, parent string)) {
+	for _, component := range components {
+		visit(component, parent)
+		walkComponents(component.children, component.templateID, visit)
+	}
+}
+
+// containsString checks if value is one of values
+func containsString(values []string, value string) bool {
+	for _, oldValue := range values {
+		if oldValue == value {
+			return true
+		}
+	}
+	return false
+}
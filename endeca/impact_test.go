@@ -0,0 +1,50 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMapImpact(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "cartridgemapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(basePath)
+
+	writeTestFile(t, basePath+"/templates/Hero/template.xml", `<ContentTemplate id="HeroBanner"/>`)
+	writeTestFile(t, basePath+"/content/Shared/HeroRule/content.xml",
+		`<ContentItem><TemplateId>HeroBanner</TemplateId><Name>Hero</Name></ContentItem>`)
+	writeTestFile(t, basePath+"/pages/Default/home/content.xml",
+		`<ContentItem><TemplateId>OneColumn</TemplateId><Property name="main"><List>`+
+			`<String>/content/Shared/HeroRule</String></List></Property></ContentItem>`)
+	writeTestFile(t, basePath+"/pages/Mobile/home/content.xml",
+		`<ContentItem><TemplateId>TwoColumn</TemplateId><Property name="left"><List>`+
+			`<ContentItem><TemplateId>HeroBanner</TemplateId></ContentItem></List></Property></ContentItem>`)
+	writeTestFile(t, basePath+"/pages/Mobile/browse/content.xml",
+		`<ContentItem><TemplateId>ProductGrid</TemplateId></ContentItem>`)
+
+	app := Application{Cartridges: MapCartridges(basePath, true, false)}
+	impact := MapImpact(basePath, app, "HeroBanner", true, false)
+	if !impact.Defined {
+		t.Errorf("MapImpact() didn't find the template")
+	}
+	if MapImpact(basePath, app, "Hero", true, false).Defined {
+		t.Errorf("MapImpact() took the template directory name for the cartridge ID")
+	}
+	expected := [][]string{{"Default", "Mobile"}, {"Default/home", "Mobile/home"}, {"Shared/HeroRule"},
+		{"OneColumn.main", "TwoColumn.left"}, {"OneColumn", "TwoColumn"}}
+	if got := [][]string{impact.Sites, impact.Pages, impact.Rules, impact.Slots, impact.Parents}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("MapImpact() sites, pages, rules, slots and parents = %q, expected %q", got, expected)
+	}
+	references := []ImpactReference{
+		{Kind: ImpactRule, File: "content/Shared/HeroRule/content.xml", Rule: "Shared/HeroRule", Name: "Hero"},
+		{Kind: ImpactPageRule, File: "pages/Default/home/content.xml", Site: "Default", Page: "home", Rule: "Shared/HeroRule", Parent: "OneColumn", Slot: "main"},
+		{Kind: ImpactPage, File: "pages/Mobile/home/content.xml", Site: "Mobile", Page: "home", Parent: "TwoColumn", Slot: "left"},
+	}
+	if !reflect.DeepEqual(impact.References, references) {
+		t.Errorf("MapImpact() references = %+v, expected %+v", impact.References, references)
+	}
+}
@@ -0,0 +1,213 @@
+package endeca
+
+import (
+	"encoding/json"
+)
+
+// cartridgeJSON is the JSON form of a Cartridge
+type cartridgeJSON struct {
+	ID           string            `json:"id"`
+	Description  string            `json:"description"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+	TemplateType string            `json:"templateType,omitempty"`
+	Properties   []propertyJSON    `json:"properties,omitempty"`
+	Sites        []string          `json:"sites"`
+	Pages        []string          `json:"pages"`
+	Rules        []string          `json:"rules"`
+	Handler      *Handler          `json:"handler,omitempty"`
+	Renderers    []string          `json:"renderers,omitempty"`
+}
+
+// propertyJSON is the JSON form of a Property
+type propertyJSON struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// componentJSON is the JSON form of a Component
+type componentJSON struct {
+	TemplateID string          `json:"templateId,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	Slot       string          `json:"slot,omitempty"`
+	Rule       string          `json:"rule,omitempty"`
+	Children   []componentJSON `json:"children,omitempty"`
+}
+
+// siteJSON is the JSON form of a Site
+type siteJSON struct {
+	ID          string                 `json:"id"`
+	DisplayName string                 `json:"displayName"`
+	URLPattern  string                 `json:"urlPattern,omitempty"`
+	DefaultPage string                 `json:"defaultPage,omitempty"`
+	FilterState map[string]interface{} `json:"filterState,omitempty"`
+	Pages       []string               `json:"pages"`
+}
+
+// pageJSON is the JSON form of a Page
+type pageJSON struct {
+	Key         string        `json:"key"`
+	Site        string        `json:"site"`
+	Name        string        `json:"name"`
+	Path        string        `json:"path"`
+	Cartridges  []string      `json:"cartridges"`
+	Rules       []string      `json:"rules"`
+	Composition componentJSON `json:"composition"`
+}
+
+// ruleJSON is the JSON form of a Rule
+type ruleJSON struct {
+	Path       string   `json:"path"`
+	Cartridges []string `json:"cartridges"`
+	Pages      []string `json:"pages"`
+}
+
+// handlerJSON is the JSON form of a Handler
+type handlerJSON struct {
+	BeanID      string            `json:"beanId"`
+	ContentType string            `json:"contentType"`
+	ClassName   string            `json:"className"`
+	Scope       string            `json:"scope,omitempty"`
+	Config      map[string]string `json:"config,omitempty"`
+}
+
+// MarshalJSON encodes the cartridge as JSON
+func (f Cartridge) MarshalJSON() ([]byte, error) {
+	var properties []propertyJSON
+	for _, property := range f.properties {
+		properties = append(properties, propertyJSON{Name: property.name, Type: property.valueType})
+	}
+	return json.Marshal(cartridgeJSON{
+		ID:           f.id,
+		Description:  f.description,
+		Descriptions: f.descriptions,
+		TemplateType: f.templateType,
+		Properties:   properties,
+		Sites:        nonNil(f.sites),
+		Pages:        nonNil(f.pages),
+		Rules:        nonNil(f.rules),
+		Handler:      f.handler,
+		Renderers:    f.renderers,
+	})
+}
+
+// MarshalJSON encodes the site as JSON
+func (s Site) MarshalJSON() ([]byte, error) {
+	return json.Marshal(siteJSON{
+		ID:          s.id,
+		DisplayName: s.displayName,
+		URLPattern:  s.urlPattern,
+		DefaultPage: s.defaultPage,
+		FilterState: s.filterState,
+		Pages:       nonNil(s.pages),
+	})
+}
+
+// MarshalJSON encodes the page as JSON
+func (p Page) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pageJSON{
+		Key:         p.GetKey(),
+		Site:        p.site,
+		Name:        p.name,
+		Path:        p.path,
+		Cartridges:  nonNil(p.cartridges),
+		Rules:       nonNil(p.rules),
+		Composition: p.composition.toJSON(),
+	})
+}
+
+// toJSON converts the component tree to its JSON form
+func (c Component) toJSON() componentJSON {
+	var children []componentJSON
+	for _, child := range c.children {
+		children = append(children, child.toJSON())
+	}
+	return componentJSON{
+		TemplateID: c.templateID,
+		Name:       c.name,
+		Slot:       c.slot,
+		Rule:       c.rule,
+		Children:   children,
+	}
+}
+
+// MarshalJSON encodes the rule as JSON
+func (r Rule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ruleJSON{
+		Path:       r.path,
+		Cartridges: nonNil(r.cartridges),
+		Pages:      nonNil(r.pages),
+	})
+}
+
+// rendererJSON is the JSON form of a Renderer
+type rendererJSON struct {
+	ContentType string `json:"contentType"`
+	Path        string `json:"path"`
+}
+
+// MarshalJSON encodes the handler as JSON
+func (h Handler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(handlerJSON{
+		BeanID:      h.beanID,
+		ContentType: h.GetContentType(),
+		ClassName:   h.className,
+		Scope:       h.scope,
+		Config:      h.config,
+	})
+}
+
+// MarshalJSON encodes the renderer as JSON
+func (r Renderer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rendererJSON{
+		ContentType: r.contentType,
+		Path:        r.path,
+	})
+}
+
+// nonNil makes sure empty lists are encoded as [] instead of null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// templateLocalesJSON is the JSON form of a TemplateLocales
+type templateLocalesJSON struct {
+	Template string           `json:"template"`
+	Keys     []string         `json:"keys"`
+	Locales  []LocaleCoverage `json:"locales"`
+}
+
+// localeCoverageJSON is the JSON form of a LocaleCoverage
+type localeCoverageJSON struct {
+	Locale  string   `json:"locale"`
+	Path    string   `json:"path"`
+	Missing []string `json:"missing"`
+	Empty   []string `json:"empty"`
+	Unused  []string `json:"unused"`
+}
+
+// MarshalJSON encodes the localization coverage of a template as JSON
+func (t TemplateLocales) MarshalJSON() ([]byte, error) {
+	locales := t.locales
+	if locales == nil {
+		locales = []LocaleCoverage{}
+	}
+	return json.Marshal(templateLocalesJSON{
+		Template: t.template,
+		Keys:     nonNil(t.keys),
+		Locales:  locales,
+	})
+}
+
+// MarshalJSON encodes the coverage of a locale file as JSON
+func (c LocaleCoverage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(localeCoverageJSON{
+		Locale:  c.locale,
+		Path:    c.path,
+		Missing: nonNil(c.missing),
+		Empty:   nonNil(c.empty),
+		Unused:  nonNil(c.unused),
+	})
+}
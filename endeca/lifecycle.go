@@ -0,0 +1,66 @@
+package endeca
+
+import (
+	"sort"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// CartridgeLifecycle is when a cartridge came and went across the commits of a repository.
+// A cartridge is used when a page uses it, directly or through a content rule.
+type CartridgeLifecycle struct {
+	ID string `json:"id"`
+	// Introduced is the first commit with the template of the cartridge
+	Introduced *utils.Commit `json:"introduced"`
+	// FirstUsed is the first commit with a page using the cartridge, nil when it never was
+	FirstUsed *utils.Commit `json:"firstUsed"`
+	// LastUsed is the last commit with a page using the cartridge, nil when it never was
+	LastUsed *utils.Commit `json:"lastUsed"`
+	// Removed is the commit that removed the template, nil when the last commit has it
+	Removed *utils.Commit `json:"removed"`
+}
+
+// Lifecycles collects the lifecycles of the cartridges of an application commit by commit
+type Lifecycles struct {
+	lifecycles map[string]*CartridgeLifecycle
+}
+
+// NewLifecycles returns lifecycles without commits
+func NewLifecycles() *Lifecycles {
+	return &Lifecycles{lifecycles: make(map[string]*CartridgeLifecycle)}
+}
+
+// Add records the application as mapped at a commit. Commits have to be added oldest first.
+func (l *Lifecycles) Add(commit utils.Commit, app Application) {
+	present := make(map[string]bool)
+	for _, cartridge := range app.Cartridges {
+		present[cartridge.id] = true
+		lifecycle, ok := l.lifecycles[cartridge.id]
+		if !ok {
+			lifecycle = &CartridgeLifecycle{ID: cartridge.id, Introduced: &commit}
+			l.lifecycles[cartridge.id] = lifecycle
+		}
+		lifecycle.Removed = nil
+		if len(app.PagesUsing(cartridge.id)) > 0 {
+			if lifecycle.FirstUsed == nil {
+				lifecycle.FirstUsed = &commit
+			}
+			lifecycle.LastUsed = &commit
+		}
+	}
+	for id, lifecycle := range l.lifecycles {
+		if !present[id] && lifecycle.Removed == nil {
+			lifecycle.Removed = &commit
+		}
+	}
+}
+
+// List returns the lifecycles by cartridge ID
+func (l *Lifecycles) List() []CartridgeLifecycle {
+	lifecycles := []CartridgeLifecycle{}
+	for _, lifecycle := range l.lifecycles {
+		lifecycles = append(lifecycles, *lifecycle)
+	}
+	sort.Slice(lifecycles, func(i, j int) bool { return lifecycles[i].ID < lifecycles[j].ID })
+	return lifecycles
+}
@@ -0,0 +1,44 @@
+package endeca
+
+import (
+	"testing"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+func TestLifecycles(t *testing.T) {
+	banner := Cartridge{id: "Banner"}
+	grid := Cartridge{id: "Grid"}
+	commits := []utils.Commit{{Hash: "a"}, {Hash: "b"}, {Hash: "c"}, {Hash: "d"}}
+	apps := []Application{
+		{Cartridges: []Cartridge{banner}},
+		{Cartridges: []Cartridge{banner, grid}, Pages: []Page{{cartridges: []string{"Banner", "Grid"}}}},
+		{Cartridges: []Cartridge{banner, grid}, Pages: []Page{{cartridges: []string{"Banner"}}}},
+		{Cartridges: []Cartridge{banner}, Pages: []Page{{cartridges: []string{"Banner"}}}},
+	}
+	lifecycles := NewLifecycles()
+	for index, commit := range commits {
+		lifecycles.Add(commit, apps[index])
+	}
+
+	hash := func(commit *utils.Commit) string {
+		if commit == nil {
+			return ""
+		}
+		return commit.Hash
+	}
+	expected := map[string][4]string{
+		"Banner": {"a", "b", "d", ""},
+		"Grid":   {"b", "b", "b", "d"},
+	}
+	list := lifecycles.List()
+	if len(list) != len(expected) {
+		t.Fatalf("List() has %d lifecycles, expected %d", len(list), len(expected))
+	}
+	for _, lifecycle := range list {
+		got := [4]string{hash(lifecycle.Introduced), hash(lifecycle.FirstUsed), hash(lifecycle.LastUsed), hash(lifecycle.Removed)}
+		if got != expected[lifecycle.ID] {
+			t.Errorf("%s introduced, first used, last used, removed = %q, expected %q", lifecycle.ID, got, expected[lifecycle.ID])
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/magiconair/properties"
+)
+
+// LocaleFallback is the chain of locales a text falls back to when a locale doesn't have a
+// key. The first locale is the one the description of a cartridge is shown in.
+var LocaleFallback = []string{"en"}
+
+// rootLocale is the locale of Resources.properties, the last fallback of every locale
+const rootLocale = ""
+
+// placeholderPattern matches the ${key} placeholders of templates
+var placeholderPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// maxPlaceholderDepth limits how deep placeholders in property values are resolved
+const maxPlaceholderDepth = 8
+
+// localeBundles are the Resources properties of a template by locale
+type localeBundles map[string]*properties.Properties
+
+// loadLocaleBundles reads every locales/Resources*.properties file of a template. Files are
+// read as UTF-8 unless they aren't valid UTF-8, then they are read as ISO-8859-1 like Java
+// does. \uXXXX escapes work in both.
+func loadLocaleBundles(templatePath string) (localeBundles, error) {
+	bundles := make(localeBundles)
+	files, err := filepath.Glob(filepath.Join(templatePath, "locales", "Resources*.properties"))
+	if err != nil {
+		return bundles, err
+	}
+	for _, file := range files {
+		locale, ok := bundleLocale(filepath.Base(file))
+		if !ok {
+			continue
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return bundles, err
+		}
+		encoding := properties.UTF8
+		if !utf8.Valid(b) {
+			encoding = properties.ISO_8859_1
+		}
+		loader := properties.Loader{Encoding: encoding, DisableExpansion: true}
+		p, err := loader.LoadBytes(b)
+		if err != nil {
+			return bundles, err
+		}
+		bundles[locale] = p
+	}
+	return bundles, nil
+}
+
+// bundleLocale returns the locale of a Resources properties file name, e.g. de_CH for
+// Resources_de_CH.properties and the root locale for Resources.properties
+func bundleLocale(name string) (string, bool) {
+	if !strings.HasPrefix(name, "Resources") || !strings.HasSuffix(name, ".properties") {
+		return "", false
+	}
+	locale := strings.TrimSuffix(strings.TrimPrefix(name, "Resources"), ".properties")
+	if locale == "" {
+		return rootLocale, true
+	}
+	if !strings.HasPrefix(locale, "_") {
+		return "", false
+	}
+	return locale[1:], true
+}
+
+// locales returns the locales of the bundles, without the root locale, sorted
+func (bundles localeBundles) locales() []string {
+	var locales []string
+	for locale := range bundles {
+		if locale != rootLocale {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// localeChain returns the locales looked in for a key of a locale: the locale, its parents
+// (de_CH falls back to de), the fallback chain with their parents and the root locale
+func localeChain(locale string) []string {
+	chain := localeParents(locale)
+	for _, fallback := range LocaleFallback {
+		for _, parent := range localeParents(fallback) {
+			chain = appendUnique(chain, parent)
+		}
+	}
+	return append(chain, rootLocale)
+}
+
+// localeParents returns a locale followed by its parents, e.g. de_CH and de
+func localeParents(locale string) []string {
+	var parents []string
+	for locale != "" {
+		parents = append(parents, locale)
+		index := strings.LastIndex(locale, "_")
+		if index < 0 {
+			break
+		}
+		locale = locale[:index]
+	}
+	return parents
+}
+
+// lookup finds a key for a locale following its locale chain
+func (bundles localeBundles) lookup(locale string, key string) (string, bool) {
+	return bundles.lookupIn(localeChain(locale), key)
+}
+
+// lookupIn finds a key in the first of the locales that has it
+func (bundles localeBundles) lookupIn(locales []string, key string) (string, bool) {
+	for _, locale := range locales {
+		if p, ok := bundles[locale]; ok {
+			if value, ok := p.Get(key); ok {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// resolve replaces the ${key} placeholders of a text with their values for a locale,
+// including placeholders in the values themselves. Placeholders that can't be resolved
+// are left in the text and returned as missing.
+func (bundles localeBundles) resolve(locale string, text string) (string, []string) {
+	var missing []string
+	var resolve func(text string, depth int) string
+	resolve = func(text string, depth int) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			key := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			value, ok := bundles.lookup(locale, key)
+			if !ok || depth >= maxPlaceholderDepth {
+				missing = appendUnique(missing, key)
+				return placeholder
+			}
+			return resolve(value, depth+1)
+		})
+	}
+	return resolve(text, 0), missing
+}
+
+// placeholderKeys returns the keys of the ${key} placeholders of a text in order
+func placeholderKeys(text string) []string {
+	var keys []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		keys = appendUnique(keys, match[1])
+	}
+	return keys
+}
+
+// hasPlaceholders checks if a text has ${key} placeholders
+func hasPlaceholders(text string) bool {
+	return placeholderPattern.MatchString(text)
+}
+
+// defaultLocale is the locale descriptions are shown in when no locale is asked for
+func defaultLocale() string {
+	if len(LocaleFallback) == 0 {
+		return rootLocale
+	}
+	return LocaleFallback[0]
+}
@@ -0,0 +1,192 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// TemplateLocales is the localization coverage of a template in each of its locales
+type TemplateLocales struct {
+	// template directory name
+	template string
+	// keys of the ${key} placeholders in template.xml
+	keys []string
+	// coverage of every Resources properties file of the template
+	locales []LocaleCoverage
+}
+
+// LocaleCoverage lists the problems of one Resources properties file of a template
+type LocaleCoverage struct {
+	// locale of the file, empty for Resources.properties
+	locale string
+	// path of the file relative to the application
+	path string
+	// keys used by the template that the locale, its parents and Resources.properties don't define
+	missing []string
+	// keys used by the template whose value is blank for the locale
+	empty []string
+	// keys of the file the template doesn't use
+	unused []string
+}
+
+// AuditLocales collects the ${key} placeholders of every template.xml, including the
+// placeholders in the values of those keys, and checks every locales/Resources_<locale>.properties
+// of the template for keys that are missing, empty or unused. A key counts as present in a
+// locale when the locale, one of its parents or Resources.properties defines it; the fallback
+// chain isn't used since falling back to another language is what the audit looks for.
+// Resources.properties is only checked for missing and empty keys when the template has no
+// other locale file. Templates without placeholders and locale files are left out.
+func AuditLocales(basePath string, DisableColor bool, Debug bool) []TemplateLocales {
+	var audits []TemplateLocales
+	utils.DisplayInfo("Starting localization audit.", DisableColor)
+	for _, templateName := range getCartridgePaths(basePath+"/templates", DisableColor, Debug) {
+		audit, err := auditTemplateLocales(basePath, templateName)
+		if err != nil {
+			utils.WithFields(utils.Fields{"file": "templates/" + templateName}).Error("Couldn't audit the locales of template", err)
+			parseFailed()
+			continue
+		}
+		if len(audit.keys) > 0 || len(audit.locales) > 0 {
+			audits = append(audits, audit)
+		}
+	}
+	utils.DisplayInfo("Finished localization audit of "+strconv.Itoa(len(audits))+" templates.", DisableColor)
+	return audits
+}
+
+// auditTemplateLocales checks the locale files of a template against its placeholders
+func auditTemplateLocales(basePath string, templateName string) (TemplateLocales, error) {
+	audit := TemplateLocales{template: templateName}
+	templatePath := basePath + "/templates/" + templateName
+	b, err := ioutil.ReadFile(templatePath + "/template.xml")
+	if err != nil {
+		return audit, err
+	}
+	audit.keys = placeholderKeys(string(b))
+	sort.Strings(audit.keys)
+	bundles, err := loadLocaleBundles(templatePath)
+	if err != nil {
+		return audit, err
+	}
+
+	// a key is used when the template or the value of a used key in any file has it
+	used := make(map[string]bool)
+	keys := append([]string{}, audit.keys...)
+	for len(keys) > 0 {
+		key := keys[0]
+		keys = keys[1:]
+		if used[key] {
+			continue
+		}
+		used[key] = true
+		for _, p := range bundles {
+			if value, ok := p.Get(key); ok {
+				keys = append(keys, placeholderKeys(value)...)
+			}
+		}
+	}
+
+	for _, locale := range bundles.locales() {
+		chain := append(localeParents(locale), rootLocale)
+		audit.locales = append(audit.locales, auditLocale(bundles, templateName, locale, chain, audit.keys))
+	}
+	if _, ok := bundles[rootLocale]; ok {
+		// without locale files Resources.properties is the only one the template is shown with
+		if len(audit.locales) == 0 {
+			audit.locales = append(audit.locales, auditLocale(bundles, templateName, rootLocale, []string{rootLocale}, audit.keys))
+		} else {
+			audit.locales = append(audit.locales, LocaleCoverage{locale: rootLocale, path: localeFilePath(templateName, rootLocale)})
+		}
+	}
+
+	for index := range audit.locales {
+		coverage := &audit.locales[index]
+		for _, key := range bundles[coverage.locale].Keys() {
+			if !used[key] {
+				coverage.unused = append(coverage.unused, key)
+			}
+		}
+		sort.Strings(coverage.missing)
+		sort.Strings(coverage.empty)
+		sort.Strings(coverage.unused)
+	}
+	return audit, nil
+}
+
+// auditLocale checks that the keys of a template, and the keys in their values, are defined
+// and not blank in one of the locales of a chain
+func auditLocale(bundles localeBundles, templateName string, locale string, chain []string, templateKeys []string) LocaleCoverage {
+	coverage := LocaleCoverage{locale: locale, path: localeFilePath(templateName, locale)}
+	checked := make(map[string]bool)
+	keys := append([]string{}, templateKeys...)
+	for len(keys) > 0 {
+		key := keys[0]
+		keys = keys[1:]
+		if checked[key] {
+			continue
+		}
+		checked[key] = true
+		value, ok := bundles.lookupIn(chain, key)
+		switch {
+		case !ok:
+			coverage.missing = append(coverage.missing, key)
+		case strings.TrimSpace(value) == "":
+			coverage.empty = append(coverage.empty, key)
+		default:
+			keys = append(keys, placeholderKeys(value)...)
+		}
+	}
+	return coverage
+}
+
+// localeFilePath returns the path of the Resources properties file of a locale of a template
+func localeFilePath(templateName string, locale string) string {
+	if locale == rootLocale {
+		return "templates/" + templateName + "/locales/Resources.properties"
+	}
+	return "templates/" + templateName + "/locales/Resources_" + locale + ".properties"
+}
+
+// GetTemplate returns the template directory name
+func (t TemplateLocales) GetTemplate() string {
+	return t.template
+}
+
+// GetKeys returns the keys of the placeholders in template.xml
+func (t TemplateLocales) GetKeys() []string {
+	return t.keys
+}
+
+// GetLocales returns the coverage of every locale file of the template
+func (t TemplateLocales) GetLocales() []LocaleCoverage {
+	return t.locales
+}
+
+// GetLocale returns the locale, empty for Resources.properties
+func (c LocaleCoverage) GetLocale() string {
+	return c.locale
+}
+
+// GetPath returns the path of the locale file relative to the application
+func (c LocaleCoverage) GetPath() string {
+	return c.path
+}
+
+// GetMissing returns the keys the locale doesn't define
+func (c LocaleCoverage) GetMissing() []string {
+	return c.missing
+}
+
+// GetEmpty returns the keys with a blank value in the locale
+func (c LocaleCoverage) GetEmpty() []string {
+	return c.empty
+}
+
+// GetUnused returns the keys of the locale file the template doesn't use
+func (c LocaleCoverage) GetUnused() []string {
+	return c.unused
+}
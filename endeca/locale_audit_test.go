@@ -0,0 +1,104 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAuditTemplateLocales(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coverage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"templates/Banner/template.xml": `<ContentTemplate id="Banner"><Description>${template.description}</Description>` +
+			`<ContentItem><Name>${label.name}</Name></ContentItem></ContentTemplate>`,
+		"templates/Banner/locales/Resources.properties":       "brand=Acme\nold.key=Old\n",
+		"templates/Banner/locales/Resources_en.properties":    "template.description=Banner by ${brand}\nlabel.name=Banner\n",
+		"templates/Banner/locales/Resources_de.properties":    "template.description=Banner von ${brand}\nlabel.name=\n",
+		"templates/Banner/locales/Resources_de_CH.properties": "label.name=Banner\nunused.key=x\n",
+		"templates/Banner/locales/Resources_fr.properties":    "label.name=Bannière\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	audit, err := auditTemplateLocales(dir, "Banner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := []string{"label.name", "template.description"}; !reflect.DeepEqual(audit.GetKeys(), keys) {
+		t.Errorf("keys = %q, expected %q", audit.GetKeys(), keys)
+	}
+
+	expected := []LocaleCoverage{
+		{locale: "de", path: "templates/Banner/locales/Resources_de.properties", empty: []string{"label.name"}},
+		{locale: "de_CH", path: "templates/Banner/locales/Resources_de_CH.properties", unused: []string{"unused.key"}},
+		{locale: "en", path: "templates/Banner/locales/Resources_en.properties"},
+		{locale: "fr", path: "templates/Banner/locales/Resources_fr.properties", missing: []string{"template.description"}},
+		{locale: "", path: "templates/Banner/locales/Resources.properties", unused: []string{"old.key"}},
+	}
+	if !reflect.DeepEqual(audit.GetLocales(), expected) {
+		t.Errorf("locales = %+v, expected %+v", audit.GetLocales(), expected)
+	}
+}
+
+func TestAuditTemplateLocalesRootOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coverage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"templates/Banner/template.xml": `<ContentTemplate id="Banner"><Description>${template.description}</Description>` +
+			`<ContentItem><Name>${label.name}</Name><Title>${label.title}</Title></ContentItem></ContentTemplate>`,
+		"templates/Banner/locales/Resources.properties": "template.description=Banner by ${brand}\nbrand=Acme\nlabel.title= \nold.key=Old\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	audit, err := auditTemplateLocales(dir, "Banner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []LocaleCoverage{
+		{locale: "", path: "templates/Banner/locales/Resources.properties",
+			missing: []string{"label.name"}, empty: []string{"label.title"}, unused: []string{"old.key"}},
+	}
+	if !reflect.DeepEqual(audit.GetLocales(), expected) {
+		t.Errorf("locales = %+v, expected %+v", audit.GetLocales(), expected)
+	}
+}
+
+func TestAuditLocalesCountsParseFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coverage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFile(t, dir+"/templates/Banner/template.xml", `<ContentTemplate id="Banner"><Description>${label.name}</Description></ContentTemplate>`)
+	writeTestFile(t, dir+"/templates/Banner/locales/Resources_en.properties", "label.name=\\uZZZZ\n")
+
+	failuresBefore := ParseFailures()
+	AuditLocales(dir, true, false)
+	if ParseFailures() != failuresBefore+1 {
+		t.Errorf("AuditLocales() didn't count the unparsable locale file as a parse failure")
+	}
+}
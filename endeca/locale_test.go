@@ -0,0 +1,67 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLocaleChain(t *testing.T) {
+	defer func(fallback []string) { LocaleFallback = fallback }(LocaleFallback)
+	LocaleFallback = []string{"en_US", "de"}
+
+	chain := localeChain("fr_CA")
+	expected := []string{"fr_CA", "fr", "en_US", "en", "de", rootLocale}
+	if !reflect.DeepEqual(chain, expected) {
+		t.Errorf("localeChain(fr_CA) = %q, expected %q", chain, expected)
+	}
+}
+
+func TestLoadLocaleBundles(t *testing.T) {
+	defer func(fallback []string) { LocaleFallback = fallback }(LocaleFallback)
+	LocaleFallback = []string{"en"}
+
+	dir, err := ioutil.TempDir("", "locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "locales"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Resources.properties":       "brand=Acme\n",
+		"Resources_en.properties":    "template.description=${label.name} banner by ${brand}\nlabel.name=Hero\n",
+		"Resources_de.properties":    "template.description=${label.name} von ${brand}\nlabel.name=Gro\xdfes Bild\n",
+		"Resources_de_CH.properties": "label.name=Grosses Bild\n",
+		"Resources_fr.properties":    "label.name=Héros\n",
+		"Resources_es.properties":    "template.description=${missing.key}\n",
+		"Other.properties":           "template.description=Ignored\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, "locales", name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	description, descriptions := getDescriptionFromProperties(dir, "${template.description}", true, false)
+	if description != "Hero banner by Acme" {
+		t.Errorf("description = %q, expected the en description", description)
+	}
+	expected := map[string]string{
+		"de":    "Großes Bild von Acme",
+		"de_CH": "Grosses Bild von Acme",
+		"en":    "Hero banner by Acme",
+		"fr":    "Héros banner by Acme",
+	}
+	if !reflect.DeepEqual(descriptions, expected) {
+		t.Errorf("descriptions = %q, expected %q", descriptions, expected)
+	}
+
+	LocaleFallback = []string{"de", "en"}
+	if description, _ := getDescriptionFromProperties(dir, "${template.description}", true, false); description != "Großes Bild von Acme" {
+		t.Errorf("description = %q, expected the de description", description)
+	}
+}
@@ -0,0 +1,280 @@
+package endeca
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// contentReferencePrefix is how pages reference content rules under the content directory
+const contentReferencePrefix = "/content/"
+
+// Page is a page of a site and the cartridges it is composed of
+type Page struct {
+	// site is the ID of the site the page belongs to
+	site string
+	// name is the path of the page within the site
+	name string
+	// path is the content.xml of the page relative to the application
+	path string
+	// cartridges are the template IDs used directly on the page, in order of appearance
+	cartridges []string
+	// rules are the content rules the page pulls content from
+	rules []string
+	// composition is the tree of content items the page is made of
+	composition Component
+}
+
+// Component is a content item placed on a page, or a reference to a content rule
+// placed in a slot of a content item
+type Component struct {
+	// templateID of the content item, empty for rule references
+	templateID string
+	// name given to the content item
+	name string
+	// slot is the property of the parent content item the component is placed in
+	slot string
+	// rule is the referenced content rule, empty for content items
+	rule string
+	// children placed in the slots of the content item
+	children []Component
+}
+
+// MapPages reads the composition of every page of every site for a given base path
+func MapPages(basePath string, DisableColor bool, Debug bool) []Page {
+	var pages []Page
+	var pagesPath = basePath + "/pages"
+
+	utils.DisplayInfo("Starting Endeca page scan.", DisableColor)
+	pageProgress := utils.StartProgress("pages", countContentFiles(basePath, pagesPath))
+	err := filepath.Walk(pagesPath, func(path string, f os.FileInfo, walkError error) error {
+		if skip, skipError := skipIgnored(basePath, path, f); skip {
+			return skipError
+		}
+		if walkError != nil || !isContentFile(f) {
+			return walkError
+		}
+		pageProgress.Increment()
+		siteName, pageName := sitePagePath(pagesPath, path)
+		if pageName == "" {
+			return nil
+		}
+		utils.WithFields(utils.Fields{"file": path, "site": siteName}).Trace("Scanning page " + pageName)
+		root, xmlErr := readContentXML(path)
+		if xmlErr != nil {
+			utils.WithFields(utils.Fields{"file": path}).Error("Couldn't read XML for page scan", xmlErr)
+			parseFailed()
+			return nil
+		}
+
+		var page = Page{
+			site:        siteName,
+			name:        pageName,
+			path:        relativePath(basePath, path),
+			composition: buildComposition(root),
+		}
+		walk([]SharedContent{root}, func(n SharedContent) bool {
+			switch n.XMLName.Local {
+			case "TemplateId":
+				page.cartridges = appendUnique(page.cartridges, strings.TrimSpace(string(n.ContentItem)))
+			case "String":
+				var stringValue = strings.TrimSpace(string(n.ContentItem))
+				if strings.HasPrefix(stringValue, contentReferencePrefix) {
+					page.rules = appendUnique(page.rules, strings.TrimPrefix(stringValue, contentReferencePrefix))
+				}
+			}
+			return true
+		})
+		utils.DisplayDebug("Mapped page "+pageName+" of site "+siteName, Debug, DisableColor)
+		pages = append(pages, page)
+		return nil
+	})
+	pageProgress.Finish()
+	if err != nil {
+		utils.DisplayError("Could not walk through pages path", err, DisableColor)
+	}
+	utils.DisplayInfo("Finished scanning Endeca pages.", DisableColor)
+
+	sort.Slice(pages, func(i, j int) bool { return pages[i].GetKey() < pages[j].GetKey() })
+	return pages
+}
+
+// buildComposition builds the component tree from the first content item in a content.xml
+func buildComposition(root SharedContent) Component {
+	if root.XMLName.Local == "ContentItem" {
+		return buildComponent(root)
+	}
+	for _, child := range root.SharedContent {
+		if component := buildComposition(child); component.templateID != "" {
+			return component
+		}
+	}
+	return Component{}
+}
+
+// buildComponent builds the component for a ContentItem element
+func buildComponent(item SharedContent) Component {
+	var component Component
+	for _, child := range item.SharedContent {
+		switch child.XMLName.Local {
+		case "TemplateId":
+			component.templateID = strings.TrimSpace(string(child.ContentItem))
+		case "Name":
+			component.name = strings.TrimSpace(string(child.ContentItem))
+		case "Property":
+			component.children = append(component.children, collectSlot(child, getAttr(child, "name"))...)
+		}
+	}
+	return component
+}
+
+// collectSlot collects the content items and rule references held by a property
+func collectSlot(n SharedContent, slot string) []Component {
+	var components []Component
+	for _, child := range n.SharedContent {
+		switch child.XMLName.Local {
+		case "ContentItem":
+			component := buildComponent(child)
+			component.slot = slot
+			components = append(components, component)
+		case "String":
+			var stringValue = strings.TrimSpace(string(child.ContentItem))
+			if strings.HasPrefix(stringValue, contentReferencePrefix) {
+				components = append(components, Component{
+					slot: slot,
+					rule: strings.TrimPrefix(stringValue, contentReferencePrefix),
+				})
+			}
+		default:
+			components = append(components, collectSlot(child, slot)...)
+		}
+	}
+	return components
+}
+
+// getAttr returns the value of an attribute of an element
+func getAttr(n SharedContent, name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// readContentXML decodes a content.xml file for walking
+func readContentXML(path string) (SharedContent, error) {
+	var n SharedContent
+	xmlFile, err := os.Open(path)
+	if err != nil {
+		return n, err
+	}
+	defer xmlFile.Close()
+	err = xml.NewDecoder(xmlFile).Decode(&n)
+	return n, err
+}
+
+// relativePath returns path relative to the base path using forward slashes
+func relativePath(basePath string, path string) string {
+	relPath, err := filepath.Rel(basePath, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// appendUnique appends value to values if it isn't in there yet
+func appendUnique(values []string, value string) []string {
+	for _, oldValue := range values {
+		if oldValue == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// GetSite returns the ID of the site the page belongs to
+func (p Page) GetSite() string {
+	return p.site
+}
+
+// GetName returns the path of the page within its site
+func (p Page) GetName() string {
+	return p.name
+}
+
+// GetKey returns the site and name of the page, unique within the application
+func (p Page) GetKey() string {
+	return p.site + "/" + p.name
+}
+
+// GetPath returns the content.xml of the page relative to the application
+func (p Page) GetPath() string {
+	return p.path
+}
+
+// GetCartridges returns the template IDs used directly on the page
+func (p Page) GetCartridges() []string {
+	return p.cartridges
+}
+
+// GetRules returns the content rules the page pulls content from
+func (p Page) GetRules() []string {
+	return p.rules
+}
+
+// GetComposition returns the tree of content items the page is made of
+func (p Page) GetComposition() Component {
+	return p.composition
+}
+
+// GetTemplateID returns the template ID of the content item, empty for rule references
+func (c Component) GetTemplateID() string {
+	return c.templateID
+}
+
+// GetName returns the name given to the content item
+func (c Component) GetName() string {
+	return c.name
+}
+
+// GetSlot returns the property of the parent content item the component is placed in
+func (c Component) GetSlot() string {
+	return c.slot
+}
+
+// GetRule returns the referenced content rule, empty for content items
+func (c Component) GetRule() string {
+	return c.rule
+}
+
+// GetChildren returns the components placed in the slots of the content item
+func (c Component) GetChildren() []Component {
+	return c.children
+}
+
+// countContentFiles counts the content.xml files under a directory that aren't ignored, to
+// report progress against
+func countContentFiles(basePath string, directory string) int {
+	var count int
+	filepath.Walk(directory, func(path string, f os.FileInfo, walkError error) error {
+		if skip, skipError := skipIgnored(basePath, path, f); skip {
+			return skipError
+		}
+		if walkError == nil && isContentFile(f) {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+// isContentFile checks if a file found walking the application is a content.xml, the
+// files the content scans read and their progress is counted in
+func isContentFile(f os.FileInfo) bool {
+	return f != nil && !f.IsDir() && f.Name() == "content.xml"
+}
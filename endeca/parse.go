@@ -0,0 +1,27 @@
+package endeca
+
+import (
+	"sync"
+)
+
+// parseFailures counts the files that couldn't be read or parsed while mapping
+var parseFailures struct {
+	sync.Mutex
+	count int
+}
+
+// parseFailed counts a file that couldn't be read or parsed
+func parseFailed() {
+	parseFailures.Lock()
+	defer parseFailures.Unlock()
+	parseFailures.count++
+}
+
+// ParseFailures returns how many files couldn't be read or parsed so far: XML that doesn't
+// decode, site definitions and locale properties files. Problems with the content of the
+// files, like missing descriptions, aren't parse failures.
+func ParseFailures() int {
+	parseFailures.Lock()
+	defer parseFailures.Unlock()
+	return parseFailures.count
+}
@@ -0,0 +1,126 @@
+package endeca
+
+import (
+	"sort"
+	"strings"
+)
+
+// Statuses of a cartridge ID across the applications of a portfolio
+const (
+	// PortfolioShared is a cartridge defined the same way in several applications
+	PortfolioShared = "shared"
+	// PortfolioDiverged is a cartridge defined in several applications with different definitions
+	PortfolioDiverged = "diverged"
+	// PortfolioUnique is a cartridge defined in a single application
+	PortfolioUnique = "unique"
+)
+
+// Portfolio is a set of mapped applications and how their cartridges relate
+type Portfolio struct {
+	Apps       []PortfolioApp       `json:"apps"`
+	Cartridges []PortfolioCartridge `json:"cartridges"`
+}
+
+// PortfolioApp is a mapped application of a portfolio
+type PortfolioApp struct {
+	Name string      `json:"name"`
+	App  Application `json:"app"`
+}
+
+// PortfolioCartridge is a cartridge ID of a portfolio and the applications defining it
+type PortfolioCartridge struct {
+	// ID is the cartridge (template) ID
+	ID string `json:"id"`
+	// Status is PortfolioShared, PortfolioDiverged or PortfolioUnique
+	Status string `json:"status"`
+	// Variants has an entry for every application, in the order of the portfolio apps: 0
+	// when the application doesn't define the cartridge, otherwise the number of its
+	// definition. Applications with the same number define the cartridge the same way.
+	Variants []int `json:"variants"`
+}
+
+// NewPortfolio compares the cartridges of the applications by ID. Definitions are compared
+// by template type, description and properties.
+func NewPortfolio(apps []PortfolioApp) Portfolio {
+	portfolio := Portfolio{Apps: apps}
+	cartridges := make(map[string]*PortfolioCartridge)
+	definitions := make(map[string][]string)
+	for index, app := range apps {
+		for _, cartridge := range app.App.Cartridges {
+			portfolioCartridge, ok := cartridges[cartridge.id]
+			if !ok {
+				portfolioCartridge = &PortfolioCartridge{ID: cartridge.id, Variants: make([]int, len(apps))}
+				cartridges[cartridge.id] = portfolioCartridge
+			}
+			definition := cartridge.definition()
+			variant := indexOf(definitions[cartridge.id], definition) + 1
+			if variant == 0 {
+				definitions[cartridge.id] = append(definitions[cartridge.id], definition)
+				variant = len(definitions[cartridge.id])
+			}
+			portfolioCartridge.Variants[index] = variant
+		}
+	}
+
+	for id, cartridge := range cartridges {
+		var defining int
+		for _, variant := range cartridge.Variants {
+			if variant > 0 {
+				defining++
+			}
+		}
+		switch {
+		case defining == 1:
+			cartridge.Status = PortfolioUnique
+		case len(definitions[id]) > 1:
+			cartridge.Status = PortfolioDiverged
+		default:
+			cartridge.Status = PortfolioShared
+		}
+		portfolio.Cartridges = append(portfolio.Cartridges, *cartridge)
+	}
+	sort.Slice(portfolio.Cartridges, func(i, j int) bool { return portfolio.Cartridges[i].ID < portfolio.Cartridges[j].ID })
+	return portfolio
+}
+
+// StatusCount returns how many cartridges of the portfolio have a status
+func (p Portfolio) StatusCount(status string) int {
+	var count int
+	for _, cartridge := range p.Cartridges {
+		if cartridge.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// UniqueCount returns how many cartridges only the application at an index defines
+func (p Portfolio) UniqueCount(index int) int {
+	var count int
+	for _, cartridge := range p.Cartridges {
+		if cartridge.Status == PortfolioUnique && cartridge.Variants[index] > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// definition describes the definition of a cartridge for comparing it across applications
+func (f Cartridge) definition() string {
+	var properties []string
+	for _, property := range f.properties {
+		properties = append(properties, property.name+":"+property.valueType)
+	}
+	sort.Strings(properties)
+	return f.templateType + "\n" + f.description + "\n" + strings.Join(properties, ",")
+}
+
+// indexOf returns the index of a value in values, -1 if it isn't in there
+func indexOf(values []string, value string) int {
+	for index, oldValue := range values {
+		if oldValue == value {
+			return index
+		}
+	}
+	return -1
+}
@@ -0,0 +1,31 @@
+package endeca
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewPortfolio(t *testing.T) {
+	banner := Cartridge{id: "Banner", templateType: "MainContent", description: "Banner"}
+	grid := Cartridge{id: "Grid", templateType: "MainContent", properties: []Property{{name: "columns", valueType: "String"}}}
+	changedGrid := grid
+	changedGrid.properties = []Property{{name: "rows", valueType: "String"}}
+
+	portfolio := NewPortfolio([]PortfolioApp{
+		{Name: "a", App: Application{Cartridges: []Cartridge{banner, grid}}},
+		{Name: "b", App: Application{Cartridges: []Cartridge{banner, changedGrid}}},
+		{Name: "c", App: Application{Cartridges: []Cartridge{grid, {id: "Footer"}}}},
+	})
+
+	expected := []PortfolioCartridge{
+		{ID: "Banner", Status: PortfolioShared, Variants: []int{1, 1, 0}},
+		{ID: "Footer", Status: PortfolioUnique, Variants: []int{0, 0, 1}},
+		{ID: "Grid", Status: PortfolioDiverged, Variants: []int{1, 2, 1}},
+	}
+	if !reflect.DeepEqual(portfolio.Cartridges, expected) {
+		t.Errorf("cartridges = %+v, expected %+v", portfolio.Cartridges, expected)
+	}
+	if count := portfolio.UniqueCount(2); count != 1 {
+		t.Errorf("UniqueCount(2) = %d, expected 1", count)
+	}
+}
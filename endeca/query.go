@@ -0,0 +1,580 @@
+package endeca
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query selects cartridges, sites, pages or rules of an application, e.g.
+//
+//	cartridges where sites contains "Mobile" and len(rules) == 0 select id, pages
+//
+// Conditions compare fields, len() of fields, strings and numbers with ==, !=, <, <=, >
+// and >=, test lists and strings with contains (case insensitive) and matches (a regular
+// expression) and combine with and, or, not and parentheses.
+type Query struct {
+	entity  string
+	where   queryNode
+	columns []string
+}
+
+// QueryResult is what a query selected, a row per cartridge, site, page or rule
+type QueryResult struct {
+	Columns []string
+	// Rows map the columns to a string, a number or a list of strings
+	Rows []map[string]interface{}
+}
+
+// queryEntity is what a query can select from
+type queryEntity struct {
+	// fields of the records, in the order of the default columns
+	fields  []string
+	records func(app Application) []map[string]interface{}
+}
+
+// queryEntities are the entities queries select from by name
+var queryEntities = map[string]queryEntity{
+	"cartridges": {
+		fields: []string{"id", "type", "description", "path", "sites", "pages", "rules", "properties", "handler", "renderers"},
+		records: func(app Application) []map[string]interface{} {
+			var records []map[string]interface{}
+			for _, cartridge := range app.Cartridges {
+				var properties []string
+				for _, property := range cartridge.properties {
+					properties = append(properties, property.name)
+				}
+				var handler string
+				if cartridge.handler != nil {
+					handler = cartridge.handler.GetClassName()
+				}
+				records = append(records, map[string]interface{}{
+					"id": cartridge.id, "type": cartridge.templateType, "description": cartridge.description,
+					"path": cartridge.path, "sites": cartridge.sites, "pages": cartridge.pages, "rules": cartridge.rules,
+					"properties": properties, "handler": handler, "renderers": cartridge.renderers,
+				})
+			}
+			return records
+		},
+	},
+	"sites": {
+		fields: []string{"id", "name", "url", "defaultPage", "pages", "cartridges"},
+		records: func(app Application) []map[string]interface{} {
+			var records []map[string]interface{}
+			for _, site := range app.Sites {
+				records = append(records, map[string]interface{}{
+					"id": site.id, "name": site.displayName, "url": site.urlPattern, "defaultPage": site.defaultPage,
+					"pages": site.pages, "cartridges": app.SiteCartridges(site.id),
+				})
+			}
+			return records
+		},
+	},
+	"pages": {
+		fields: []string{"key", "site", "name", "path", "cartridges", "rules"},
+		records: func(app Application) []map[string]interface{} {
+			var records []map[string]interface{}
+			for _, page := range app.Pages {
+				records = append(records, map[string]interface{}{
+					"key": page.GetKey(), "site": page.site, "name": page.name, "path": page.path,
+					"cartridges": app.PageCartridges(page), "rules": page.rules,
+				})
+			}
+			return records
+		},
+	},
+	"rules": {
+		fields: []string{"path", "cartridges", "pages"},
+		records: func(app Application) []map[string]interface{} {
+			var records []map[string]interface{}
+			for _, rule := range app.Rules {
+				records = append(records, map[string]interface{}{
+					"path": rule.path, "cartridges": rule.cartridges, "pages": rule.pages,
+				})
+			}
+			return records
+		},
+	},
+}
+
+// QueryEntities returns the names of the entities queries select from
+func QueryEntities() []string {
+	var names []string
+	for name := range queryEntities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseQuery parses a query, see Query
+func ParseQuery(text string) (Query, error) {
+	tokens, err := tokenizeQuery(text)
+	if err != nil {
+		return Query{}, err
+	}
+	p := &queryParser{tokens: tokens}
+	entityName := p.next()
+	entity, ok := queryEntities[strings.ToLower(entityName.text)]
+	if entityName.kind != tokenIdent || !ok {
+		return Query{}, errors.New("query has to start with one of " + strings.Join(QueryEntities(), ", ") + ", not " + entityName.String())
+	}
+	p.fields = entity.fields
+	query := Query{entity: strings.ToLower(entityName.text), columns: entity.fields}
+	if p.keyword("where") {
+		if query.where, err = p.parseOr(); err != nil {
+			return Query{}, err
+		}
+	}
+	if p.keyword("select") {
+		query.columns = nil
+		for {
+			field := p.next()
+			if field.kind != tokenIdent || !p.isField(field.text) {
+				return Query{}, errors.New("select expects a field of " + query.entity + " (" + strings.Join(entity.fields, ", ") + "), not " + field.String())
+			}
+			query.columns = append(query.columns, field.text)
+			if p.peek().text != "," {
+				break
+			}
+			p.next()
+		}
+	}
+	if token := p.peek(); token.kind != tokenEnd {
+		return Query{}, errors.New("unexpected " + token.String() + " in query")
+	}
+	return query, nil
+}
+
+// Run selects the rows of the application the query matches
+func (q Query) Run(app Application) (QueryResult, error) {
+	result := QueryResult{Columns: q.columns, Rows: []map[string]interface{}{}}
+	for _, record := range queryEntities[q.entity].records(app) {
+		if q.where != nil {
+			value, err := q.where.eval(record)
+			if err != nil {
+				return result, err
+			}
+			if !truthy(value) {
+				continue
+			}
+		}
+		row := make(map[string]interface{})
+		for _, column := range q.columns {
+			row[column] = record[column]
+			if list, ok := row[column].([]string); ok && list == nil {
+				row[column] = []string{}
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// FormatQueryValue shows a value of a query result, lists joined by the separator
+func FormatQueryValue(value interface{}, separator string) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, separator)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+// Tokens of a query
+const (
+	tokenEnd = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+// querySymbols are the operators and punctuation of queries
+var querySymbols = map[string]bool{"==": true, "!=": true, "<=": true, ">=": true, "<": true, ">": true, "(": true, ")": true, ",": true}
+
+// queryComparisons are the operators comparing two values
+var queryComparisons = map[string]bool{"==": true, "!=": true, "<=": true, ">=": true, "<": true, ">": true}
+
+// queryToken is a word, string, number or operator of a query
+type queryToken struct {
+	kind int
+	text string
+}
+
+// String shows the token in error messages
+func (t queryToken) String() string {
+	switch t.kind {
+	case tokenEnd:
+		return "end of query"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// tokenizeQuery splits a query into tokens, ending with a tokenEnd
+func tokenizeQuery(text string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var value []rune
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				value = append(value, runes[j])
+			}
+			if j == len(runes) {
+				return nil, errors.New("unterminated string in query")
+			}
+			tokens = append(tokens, queryToken{tokenString, string(value)})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, queryToken{tokenNumber, string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, queryToken{tokenIdent, string(runes[i:j])})
+			i = j
+		default:
+			symbol := string(r)
+			if i+1 < len(runes) && querySymbols[string(runes[i:i+2])] {
+				symbol = string(runes[i : i+2])
+			}
+			if !querySymbols[symbol] {
+				return nil, errors.New("unexpected character " + strconv.QuoteRune(r) + " in query")
+			}
+			tokens = append(tokens, queryToken{tokenSymbol, symbol})
+			i += len([]rune(symbol))
+		}
+	}
+	return append(tokens, queryToken{kind: tokenEnd}), nil
+}
+
+// queryParser parses the tokens of a query into conditions over the fields of an entity
+type queryParser struct {
+	tokens   []queryToken
+	position int
+	fields   []string
+}
+
+// peek returns the next token without consuming it
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.position]
+}
+
+// next consumes the next token
+func (p *queryParser) next() queryToken {
+	token := p.tokens[p.position]
+	if token.kind != tokenEnd {
+		p.position++
+	}
+	return token
+}
+
+// keyword consumes the next token if it is the keyword
+func (p *queryParser) keyword(word string) bool {
+	if token := p.peek(); token.kind == tokenIdent && strings.EqualFold(token.text, word) {
+		p.position++
+		return true
+	}
+	return false
+}
+
+// isField checks if name is a field of the entity
+func (p *queryParser) isField(name string) bool {
+	for _, field := range p.fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.keyword("or") {
+		var right queryNode
+		if right, err = p.parseAnd(); err == nil {
+			left = logicNode{or: true, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	for err == nil && p.keyword("and") {
+		var right queryNode
+		if right, err = p.parseNot(); err == nil {
+			left = logicNode{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.keyword("not") {
+		operand, err := p.parseNot()
+		return notNode{operand}, err
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (queryNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	operator := p.peek()
+	switch {
+	case operator.kind == tokenSymbol && queryComparisons[operator.text]:
+		p.next()
+		right, err := p.parseOperand()
+		return compareNode{operator: operator.text, left: left, right: right}, err
+	case p.keyword("contains"):
+		right, err := p.parseOperand()
+		return containsNode{left: left, right: right}, err
+	case p.keyword("matches"):
+		pattern := p.next()
+		if pattern.kind != tokenString {
+			return nil, errors.New("matches expects a regular expression string, not " + pattern.String())
+		}
+		expression, err := regexp.Compile(pattern.text)
+		if err != nil {
+			return nil, err
+		}
+		return matchesNode{left: left, expression: expression}, nil
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseOperand() (queryNode, error) {
+	token := p.next()
+	switch token.kind {
+	case tokenString:
+		return literalNode{token.text}, nil
+	case tokenNumber:
+		number, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, errors.New("bad number " + token.text + " in query")
+		}
+		return literalNode{number}, nil
+	case tokenSymbol:
+		if token.text == "(" {
+			node, err := p.parseOr()
+			if err == nil {
+				if closing := p.next(); closing.text != ")" {
+					err = errors.New("expected ')', not " + closing.String())
+				}
+			}
+			return node, err
+		}
+	case tokenIdent:
+		if strings.EqualFold(token.text, "len") && p.peek().text == "(" {
+			p.next()
+			operand, err := p.parseOperand()
+			if err == nil {
+				if closing := p.next(); closing.text != ")" {
+					err = errors.New("expected ')' after len(, not " + closing.String())
+				}
+			}
+			return lenNode{operand}, err
+		}
+		if p.isField(token.text) {
+			return fieldNode{token.text}, nil
+		}
+		return nil, errors.New("unknown field " + token.String() + ", use one of " + strings.Join(p.fields, ", "))
+	}
+	return nil, errors.New("unexpected " + token.String() + " in query")
+}
+
+// queryNode is part of a condition, evaluated against a record
+type queryNode interface {
+	eval(record map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct{ value interface{} }
+
+type fieldNode struct{ name string }
+
+type lenNode struct{ operand queryNode }
+
+type notNode struct{ operand queryNode }
+
+type logicNode struct {
+	or          bool
+	left, right queryNode
+}
+
+type compareNode struct {
+	operator    string
+	left, right queryNode
+}
+
+type containsNode struct{ left, right queryNode }
+
+type matchesNode struct {
+	left       queryNode
+	expression *regexp.Regexp
+}
+
+func (n literalNode) eval(record map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n fieldNode) eval(record map[string]interface{}) (interface{}, error) {
+	return record[n.name], nil
+}
+
+func (n lenNode) eval(record map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(record)
+	switch v := value.(type) {
+	case []string:
+		return float64(len(v)), err
+	case string:
+		return float64(len(v)), err
+	}
+	return nil, errors.New("len() needs a list or string")
+}
+
+func (n notNode) eval(record map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(record)
+	return !truthy(value), err
+}
+
+func (n logicNode) eval(record map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(record)
+	if err != nil || truthy(left) == n.or {
+		return truthy(left), err
+	}
+	right, err := n.right.eval(record)
+	return truthy(right), err
+}
+
+func (n compareNode) eval(record map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	var order int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, errors.New("can't compare a number with " + FormatQueryValue(right, ", "))
+		}
+		if l < r {
+			order = -1
+		} else if l > r {
+			order = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, errors.New("can't compare a string with " + FormatQueryValue(right, ", "))
+		}
+		order = strings.Compare(l, r)
+	default:
+		return nil, errors.New("can only compare strings and numbers, use contains or len() on lists")
+	}
+	switch n.operator {
+	case "==":
+		return order == 0, nil
+	case "!=":
+		return order != 0, nil
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	}
+	return order >= 0, nil
+}
+
+func (n containsNode) eval(record map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	wanted := FormatQueryValue(right, ", ")
+	switch l := left.(type) {
+	case []string:
+		for _, value := range l {
+			if strings.EqualFold(value, wanted) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		return strings.Contains(strings.ToLower(l), strings.ToLower(wanted)), nil
+	}
+	return nil, errors.New("contains needs a list or string")
+}
+
+func (n matchesNode) eval(record map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	switch l := left.(type) {
+	case []string:
+		for _, value := range l {
+			if n.expression.MatchString(value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		return n.expression.MatchString(l), nil
+	}
+	return nil, errors.New("matches needs a list or string")
+}
+
+// truthy is whether a value counts as true in a condition: true, a number other than 0 or
+// a string or list that isn't empty
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []string:
+		return len(v) > 0
+	}
+	return false
+}
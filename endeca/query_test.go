@@ -0,0 +1,55 @@
+package endeca
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	app := Application{
+		Cartridges: []Cartridge{
+			{id: "HeroBanner", templateType: "MainContent", sites: []string{"Default", "Mobile"}, rules: []string{"Shared/HeroRule"}},
+			{id: "ProductGrid", templateType: "MainContent", sites: []string{"Mobile"}},
+			{id: "Unused", templateType: "SecondaryContent"},
+		},
+		Pages: []Page{{site: "Mobile", name: "home", cartridges: []string{"ProductGrid"}, rules: []string{"Shared/HeroRule"}}},
+		Rules: []Rule{{path: "Shared/HeroRule", cartridges: []string{"HeroBanner"}, pages: []string{"Mobile/home"}}},
+	}
+	tests := []struct {
+		query string
+		rows  []string
+	}{
+		{`cartridges where sites contains "mobile" and len(rules) == 0`, []string{"ProductGrid"}},
+		{`cartridges where not sites or id matches "^Hero"`, []string{"HeroBanner", "Unused"}},
+		{`cartridges where type != "MainContent" select id`, []string{"Unused"}},
+		{`cartridges where (len(sites) >= 1 and len(sites) < 2)`, []string{"ProductGrid"}},
+		{`pages where cartridges contains 'HeroBanner' select key`, []string{"Mobile/home"}},
+		{`rules where pages select path`, []string{"Shared/HeroRule"}},
+	}
+	for _, test := range tests {
+		query, err := ParseQuery(test.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) failed: %v", test.query, err)
+			continue
+		}
+		result, err := query.Run(app)
+		if err != nil {
+			t.Errorf("Run(%q) failed: %v", test.query, err)
+			continue
+		}
+		var rows []string
+		for _, row := range result.Rows {
+			rows = append(rows, FormatQueryValue(row[result.Columns[0]], ","))
+		}
+		if !reflect.DeepEqual(rows, test.rows) {
+			t.Errorf("Run(%q) = %q, expected %q", test.query, rows, test.rows)
+		}
+	}
+
+	for _, bad := range []string{`templates`, `cartridges where`, `cartridges where id = "x"`, `cartridges where size > 1`,
+		`cartridges select`, `cartridges where id matches "("`, `cartridges where (id == "x"`} {
+		if _, err := ParseQuery(bad); err == nil {
+			t.Errorf("ParseQuery(%q) should fail", bad)
+		}
+	}
+}
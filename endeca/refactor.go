@@ -0,0 +1,286 @@
+package endeca
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Actions of content changes
+const (
+	// ChangeRename is a TemplateId or template id rewritten to the new ID
+	ChangeRename = "rename"
+	// ChangeRemove is a content item of the template stripped from a file
+	ChangeRemove = "remove"
+	// ChangeSkip is a content item that has to be changed by hand
+	ChangeSkip = "skip"
+)
+
+// ContentChange is a change a refactoring made, or couldn't make, to a file of an export
+type ContentChange struct {
+	// File is the path of the file relative to the application
+	File string `json:"file"`
+	// Line is 0 for changes to a whole file or directory
+	Line     int    `json:"line,omitempty"`
+	Action   string `json:"action"`
+	Template string `json:"template"`
+	// Name given to the content item
+	Name   string `json:"name,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// contentItemSpan is where a content item is in the bytes of a content.xml
+type contentItemSpan struct {
+	// start and end of the element, end is past its end tag
+	start, end int
+	// idStart and idEnd are the text of its TemplateId, both 0 without one
+	idStart, idEnd int
+	templateID     string
+	name           string
+	// root is set for content items that aren't placed in another content item
+	root bool
+}
+
+// scanContentItemSpans finds every content item of a content.xml and where its element and
+// TemplateId text are. Only the offsets of the original bytes are used for rewriting, so
+// everything else in the file stays exactly as it is.
+func scanContentItemSpans(content []byte) ([]contentItemSpan, error) {
+	type frame struct {
+		name string
+		// item is the index of the content item span of ContentItem frames, -1 otherwise
+		item int
+		// innerStart is where the content of the element starts
+		innerStart int
+		text       bytes.Buffer
+	}
+	var spans []contentItemSpan
+	var stack []*frame
+	parentItem := func(depth int) int {
+		for index := depth; index >= 0; index-- {
+			if stack[index].item >= 0 {
+				return stack[index].item
+			}
+		}
+		return -1
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		before := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		after := int(decoder.InputOffset())
+		switch t := token.(type) {
+		case xml.StartElement:
+			f := &frame{name: t.Name.Local, item: -1, innerStart: after}
+			if t.Name.Local == "ContentItem" {
+				f.item = len(spans)
+				spans = append(spans, contentItemSpan{start: before, root: parentItem(len(stack)-1) < 0})
+			}
+			stack = append(stack, f)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, errors.New("unexpected end element " + t.Name.Local)
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if f.item >= 0 {
+				spans[f.item].end = after
+				continue
+			}
+			// only the TemplateId and Name directly in a content item are its own
+			if len(stack) == 0 || stack[len(stack)-1].item < 0 {
+				continue
+			}
+			span := &spans[stack[len(stack)-1].item]
+			switch f.name {
+			case "TemplateId":
+				span.templateID = strings.TrimSpace(f.text.String())
+				span.idStart, span.idEnd = f.innerStart, before
+			case "Name":
+				span.name = strings.TrimSpace(f.text.String())
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return nil, errors.New("element " + stack[len(stack)-1].name + " isn't closed")
+	}
+	return spans, nil
+}
+
+// lineAt returns the line an offset is on
+func lineAt(content []byte, offset int) int {
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
+// RenameTemplate rewrites the TemplateId of every content item of a template in a content.xml
+// to a new template ID. The whitespace around the ID and every other byte of the file are
+// kept as they are.
+func RenameTemplate(content []byte, file string, from string, to string) ([]byte, []ContentChange, error) {
+	spans, err := scanContentItemSpans(content)
+	if err != nil {
+		return content, nil, err
+	}
+	// a content item can have its TemplateId after the content items placed in it
+	sort.Slice(spans, func(i, j int) bool { return spans[i].idStart < spans[j].idStart })
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(to))
+
+	var changes []ContentChange
+	var out bytes.Buffer
+	position := 0
+	for _, span := range spans {
+		if span.templateID != from {
+			continue
+		}
+		inner := content[span.idStart:span.idEnd]
+		trimmed := bytes.TrimSpace(inner)
+		leading := bytes.Index(inner, trimmed)
+		out.Write(content[position : span.idStart+leading])
+		out.Write(escaped.Bytes())
+		position = span.idStart + leading + len(trimmed)
+		changes = append(changes, ContentChange{File: file, Line: lineAt(content, span.idStart), Action: ChangeRename,
+			Template: from, Name: span.name, Detail: "TemplateId " + from + " -> " + to})
+	}
+	if len(changes) == 0 {
+		return content, nil, nil
+	}
+	out.Write(content[position:])
+	return out.Bytes(), changes, nil
+}
+
+// RemoveTemplate strips every content item of a template, with the content items placed in
+// it, from a content.xml. A content item on a line of its own is removed with its line.
+// Content items at the root of the file can't be removed without the page or rule and are
+// reported as skipped.
+func RemoveTemplate(content []byte, file string, templateID string) ([]byte, []ContentChange, error) {
+	spans, err := scanContentItemSpans(content)
+	if err != nil {
+		return content, nil, err
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var changes []ContentChange
+	var out bytes.Buffer
+	position := 0
+	for _, span := range spans {
+		if span.templateID != templateID || span.start < position {
+			continue
+		}
+		change := ContentChange{File: file, Line: lineAt(content, span.start), Template: templateID, Name: span.name}
+		if span.root {
+			change.Action = ChangeSkip
+			change.Detail = "the content item is the root of the file, remove the page or rule by hand"
+			changes = append(changes, change)
+			continue
+		}
+		start, end := span.start, span.end
+		lineStart := start
+		for lineStart > 0 && (content[lineStart-1] == ' ' || content[lineStart-1] == '\t') {
+			lineStart--
+		}
+		lineEnd := end
+		for lineEnd < len(content) && (content[lineEnd] == ' ' || content[lineEnd] == '\t' || content[lineEnd] == '\r') {
+			lineEnd++
+		}
+		if (lineStart == 0 || content[lineStart-1] == '\n') && lineEnd < len(content) && content[lineEnd] == '\n' {
+			start, end = lineStart, lineEnd+1
+		}
+		out.Write(content[position:start])
+		position = end
+		change.Action = ChangeRemove
+		change.Detail = "removed content item"
+		changes = append(changes, change)
+	}
+	out.Write(content[position:])
+	return out.Bytes(), changes, nil
+}
+
+// TemplateID returns the cartridge ID of a template.xml in a template directory. Like
+// MapCartridges a template without an id attribute has its directory name as ID.
+func TemplateID(content []byte, directory string) (string, error) {
+	var contentTemplate ContentTemplate
+	if err := xml.Unmarshal(content, &contentTemplate); err != nil {
+		return directory, err
+	}
+	if contentTemplate.ID == "" {
+		return directory, nil
+	}
+	return contentTemplate.ID, nil
+}
+
+// RenameTemplateDefinition rewrites the id attribute of the ContentTemplate element of a
+// template.xml from one template ID to another, leaving every other byte as it is. It
+// returns false when the template doesn't have the ID.
+func RenameTemplateDefinition(content []byte, from string, to string) ([]byte, bool, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		before := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return content, false, nil
+		}
+		if err != nil {
+			return content, false, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "ContentTemplate" {
+			return content, false, nil
+		}
+		after := int(decoder.InputOffset())
+		tag := content[before:after]
+		idAttr := regexp.MustCompile(`(\sid\s*=\s*)("` + regexp.QuoteMeta(from) + `"|'` + regexp.QuoteMeta(from) + `')`)
+		location := idAttr.FindSubmatchIndex(tag)
+		if location == nil {
+			return content, false, nil
+		}
+		var escaped bytes.Buffer
+		xml.EscapeText(&escaped, []byte(to))
+		quote := tag[location[4]]
+		var out bytes.Buffer
+		out.Write(content[:before+location[4]])
+		out.WriteByte(quote)
+		out.Write(escaped.Bytes())
+		out.WriteByte(quote)
+		out.Write(content[before+location[5]:])
+		return out.Bytes(), true, nil
+	}
+}
+
+// RefactorReport is what a refactoring of an export changed
+type RefactorReport struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+	// Action is ChangeRename or ChangeRemove
+	Action      string          `json:"action"`
+	Template    string          `json:"template"`
+	NewTemplate string          `json:"newTemplate,omitempty"`
+	Changes     []ContentChange `json:"changes"`
+}
+
+// Count returns the number of changes with an action
+func (r RefactorReport) Count(action string) int {
+	var count int
+	for _, change := range r.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,110 @@
+package endeca
+
+import (
+	"testing"
+)
+
+const refactorContent = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- home page -->
+<ContentItem xmlns="http://endeca.com/schema/content/2008" type="PageTemplate">
+  <TemplateId>OneColumn</TemplateId>
+  <Property name="main">
+    <List>
+      <ContentItem type="MainContent"><TemplateId> HeroBanner </TemplateId><Name>Hero &amp; more</Name></ContentItem>
+      <ContentItem type="MainContent">
+        <Property name="slides"><List><ContentItem><TemplateId>HeroBanner</TemplateId></ContentItem></List></Property>
+        <TemplateId>Carousel</TemplateId>
+      </ContentItem>
+      <String>/content/Shared/HeroRule</String>
+    </List>
+  </Property>
+</ContentItem>
+`
+
+func TestRenameTemplate(t *testing.T) {
+	renamed, changes, err := RenameTemplate([]byte(refactorContent), "pages/Default/home/content.xml", "HeroBanner", "Hero")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- home page -->
+<ContentItem xmlns="http://endeca.com/schema/content/2008" type="PageTemplate">
+  <TemplateId>OneColumn</TemplateId>
+  <Property name="main">
+    <List>
+      <ContentItem type="MainContent"><TemplateId> Hero </TemplateId><Name>Hero &amp; more</Name></ContentItem>
+      <ContentItem type="MainContent">
+        <Property name="slides"><List><ContentItem><TemplateId>Hero</TemplateId></ContentItem></List></Property>
+        <TemplateId>Carousel</TemplateId>
+      </ContentItem>
+      <String>/content/Shared/HeroRule</String>
+    </List>
+  </Property>
+</ContentItem>
+`
+	if string(renamed) != expected {
+		t.Errorf("RenameTemplate() =\n%s\nexpected\n%s", renamed, expected)
+	}
+	if len(changes) != 2 || changes[0].Line != 7 || changes[0].Name != "Hero & more" || changes[1].Line != 9 {
+		t.Errorf("RenameTemplate() changes = %+v", changes)
+	}
+}
+
+func TestRemoveTemplate(t *testing.T) {
+	removed, changes, err := RemoveTemplate([]byte(refactorContent), "pages/Default/home/content.xml", "Carousel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- home page -->
+<ContentItem xmlns="http://endeca.com/schema/content/2008" type="PageTemplate">
+  <TemplateId>OneColumn</TemplateId>
+  <Property name="main">
+    <List>
+      <ContentItem type="MainContent"><TemplateId> HeroBanner </TemplateId><Name>Hero &amp; more</Name></ContentItem>
+      <String>/content/Shared/HeroRule</String>
+    </List>
+  </Property>
+</ContentItem>
+`
+	if string(removed) != expected {
+		t.Errorf("RemoveTemplate() =\n%s\nexpected\n%s", removed, expected)
+	}
+	if len(changes) != 1 || changes[0].Action != ChangeRemove || changes[0].Line != 8 {
+		t.Errorf("RemoveTemplate() changes = %+v", changes)
+	}
+
+	unchanged, changes, err := RemoveTemplate([]byte(refactorContent), "pages/Default/home/content.xml", "OneColumn")
+	if err != nil || string(unchanged) != refactorContent || len(changes) != 1 || changes[0].Action != ChangeSkip {
+		t.Errorf("RemoveTemplate() of the root should be skipped, got changes %+v, error %v", changes, err)
+	}
+}
+
+func TestRenameTemplateDefinition(t *testing.T) {
+	template := `<?xml version="1.0"?>
+<ContentTemplate xmlns="http://endeca.com/schema/content-template/2008" type='MainContent' id='HeroBanner'>
+  <Description>HeroBanner</Description>
+</ContentTemplate>`
+	renamed, ok, err := RenameTemplateDefinition([]byte(template), "HeroBanner", "Hero")
+	expected := `<?xml version="1.0"?>
+<ContentTemplate xmlns="http://endeca.com/schema/content-template/2008" type='MainContent' id='Hero'>
+  <Description>HeroBanner</Description>
+</ContentTemplate>`
+	if err != nil || !ok || string(renamed) != expected {
+		t.Errorf("RenameTemplateDefinition() = %s, %v, %v", renamed, ok, err)
+	}
+	if _, ok, _ := RenameTemplateDefinition([]byte(template), "Other", "Hero"); ok {
+		t.Errorf("RenameTemplateDefinition() renamed a template with another ID")
+	}
+}
+
+func TestTemplateID(t *testing.T) {
+	id, err := TemplateID([]byte(`<ContentTemplate type="MainContent" id="HeroBanner"/>`), "Hero")
+	if err != nil || id != "HeroBanner" {
+		t.Errorf("TemplateID() = %s, %v, expected the id attribute HeroBanner", id, err)
+	}
+	id, err = TemplateID([]byte(`<ContentTemplate type="MainContent"/>`), "Hero")
+	if err != nil || id != "Hero" {
+		t.Errorf("TemplateID() = %s, %v, expected the directory name Hero", id, err)
+	}
+}
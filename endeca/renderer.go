@@ -0,0 +1,182 @@
+package endeca
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// jspViewsPath is the directory JSP renderers live in, one directory per content item type
+const jspViewsPath = "WEB-INF/views"
+
+// sharedViewDirectories are directories under WEB-INF/views holding JSPs shared by the views
+// instead of the views of a content item type
+var sharedViewDirectories = []string{"common", "shared", "includes", "fragments", "layouts", "partials", "tags"}
+
+// rendererSourceExtensions are the files the renderer patterns are matched against
+var rendererSourceExtensions = []string{".js", ".jsx", ".ts", ".tsx"}
+
+// errNoCaptureGroup is returned for renderer patterns that can't match a content item type
+var errNoCaptureGroup = errors.New("pattern must have a capture group for the content item type")
+
+// Renderer is a frontend view rendering a content item type in the storefront
+type Renderer struct {
+	// contentType is the content item type, i.e. the template ID, the renderer is for
+	contentType string
+	// path is the source file of the renderer relative to the storefront directory
+	path string
+}
+
+// MapRenderers scans a storefront source directory for cartridge renderers. JSP views are
+// found under WEB-INF/views/<type>, JS components are found by matching the given patterns
+// against the JS sources. Every pattern must have one capture group matching the type.
+func MapRenderers(storefrontPath string, patterns []string, DisableColor bool, Debug bool) ([]Renderer, error) {
+	var renderers []Renderer
+	var expressions []*regexp.Regexp
+
+	for _, pattern := range patterns {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			utils.DisplayError("Couldn't compile renderer pattern "+pattern, err, DisableColor)
+			return renderers, err
+		}
+		if expression.NumSubexp() < 1 {
+			err = errNoCaptureGroup
+			utils.DisplayError("Renderer pattern "+pattern+" has no capture group.", err, DisableColor)
+			return renderers, err
+		}
+		expressions = append(expressions, expression)
+	}
+
+	utils.DisplayInfo("Starting storefront renderer scan.", DisableColor)
+	err := filepath.Walk(storefrontPath, func(path string, f os.FileInfo, walkError error) error {
+		if walkError != nil {
+			return walkError
+		}
+		if f.IsDir() && f.Name() == "node_modules" {
+			return filepath.SkipDir
+		}
+		if f.IsDir() {
+			return nil
+		}
+		relPath, relError := filepath.Rel(storefrontPath, path)
+		if relError != nil {
+			return relError
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if strings.HasSuffix(relPath, ".jsp") {
+			if contentType := jspContentType(relPath); contentType != "" {
+				utils.DisplayDebug("Found JSP renderer for "+contentType+" in "+relPath, Debug, DisableColor)
+				renderers = append(renderers, Renderer{contentType: contentType, path: relPath})
+			}
+			return nil
+		}
+
+		if len(expressions) == 0 || !isRendererSource(relPath) {
+			return nil
+		}
+		b, readError := ioutil.ReadFile(path)
+		if readError != nil {
+			utils.WithFields(utils.Fields{"file": relPath}).Error("Couldn't read storefront source", readError)
+			return nil
+		}
+		for _, expression := range expressions {
+			for _, match := range expression.FindAllSubmatch(b, -1) {
+				contentType := string(match[1])
+				utils.DisplayDebug("Found JS renderer for "+contentType+" in "+relPath, Debug, DisableColor)
+				renderers = append(renderers, Renderer{contentType: contentType, path: relPath})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.DisplayError("Could not scan storefront directory.", err, DisableColor)
+		return renderers, err
+	}
+	utils.DisplayInfo("Finished scanning storefront renderers.", DisableColor)
+
+	sort.Slice(renderers, func(i, j int) bool {
+		if renderers[i].contentType == renderers[j].contentType {
+			return renderers[i].path < renderers[j].path
+		}
+		return renderers[i].contentType < renderers[j].contentType
+	})
+	return renderers, nil
+}
+
+// LinkRenderers links every cartridge to the renderers of its template ID. It returns the
+// linked cartridges and the renderers that don't belong to any cartridge.
+func LinkRenderers(cartridges []Cartridge, renderers []Renderer, DisableColor bool, Debug bool) ([]Cartridge, []Renderer) {
+	var orphanRenderers []Renderer
+	used := make(map[Renderer]bool)
+
+	for index, cartridge := range cartridges {
+		for _, renderer := range renderers {
+			if renderer.contentType == cartridge.id {
+				used[renderer] = true
+				cartridges[index].renderers = append(cartridges[index].renderers, renderer.path)
+			}
+		}
+		if len(cartridges[index].renderers) == 0 {
+			utils.DisplayWarning("No renderer found for cartridge "+cartridge.id, DisableColor)
+		}
+	}
+
+	for _, renderer := range renderers {
+		if !used[renderer] {
+			utils.DisplayWarning("Renderer "+renderer.path+" for "+renderer.contentType+" has no cartridge", DisableColor)
+			orphanRenderers = append(orphanRenderers, renderer)
+		}
+	}
+	return cartridges, orphanRenderers
+}
+
+// jspContentType returns the content item type of a JSP under WEB-INF/views, which is
+// the directory right under the views directory, so views/<type>/partials/x.jsp belongs to
+// <type>, or the JSP name if it sits directly in the views directory. JSPs in shared
+// directories like views/common don't render a content item type.
+func jspContentType(relPath string) string {
+	index := strings.Index(relPath, jspViewsPath+"/")
+	if index < 0 {
+		return ""
+	}
+	viewPath := relPath[index+len(jspViewsPath)+1:]
+	slash := strings.Index(viewPath, "/")
+	if slash < 0 {
+		return strings.TrimSuffix(viewPath, ".jsp")
+	}
+	contentType := viewPath[:slash]
+	for _, shared := range sharedViewDirectories {
+		if strings.EqualFold(contentType, shared) {
+			return ""
+		}
+	}
+	return contentType
+}
+
+// isRendererSource checks if a file is a JS source the renderer patterns apply to
+func isRendererSource(path string) bool {
+	for _, extension := range rendererSourceExtensions {
+		if strings.HasSuffix(path, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetContentType returns the content item type the renderer is for
+func (r Renderer) GetContentType() string {
+	return r.contentType
+}
+
+// GetPath returns the source file of the renderer
+func (r Renderer) GetPath() string {
+	return r.path
+}
@@ -0,0 +1,75 @@
+package endeca
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMapRenderers(t *testing.T) {
+	storefrontPath, err := ioutil.TempDir("", "cartridgemapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storefrontPath)
+
+	writeTestFile(t, storefrontPath+"/WEB-INF/views/HeroBanner/HeroBanner.jsp", "")
+	writeTestFile(t, storefrontPath+"/WEB-INF/views/HeroBanner/partials/slide.jsp", "")
+	writeTestFile(t, storefrontPath+"/WEB-INF/views/ProductGrid.jsp", "")
+	writeTestFile(t, storefrontPath+"/WEB-INF/views/common/header.jsp", "")
+	writeTestFile(t, storefrontPath+"/src/components.js", `export default { "Carousel": Carousel }`)
+	writeTestFile(t, storefrontPath+"/node_modules/lib/index.js", `{ "Vendor": Vendor }`)
+
+	renderers, err := MapRenderers(storefrontPath, []string{`"(\w+)": \w+`}, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Renderer{
+		{contentType: "Carousel", path: "src/components.js"},
+		{contentType: "HeroBanner", path: "WEB-INF/views/HeroBanner/HeroBanner.jsp"},
+		{contentType: "HeroBanner", path: "WEB-INF/views/HeroBanner/partials/slide.jsp"},
+		{contentType: "ProductGrid", path: "WEB-INF/views/ProductGrid.jsp"},
+	}
+	if !reflect.DeepEqual(renderers, expected) {
+		t.Errorf("MapRenderers() = %+v, expected %+v", renderers, expected)
+	}
+
+	if _, err := MapRenderers(storefrontPath, []string{`\w+`}, true, false); err != errNoCaptureGroup {
+		t.Errorf("MapRenderers() with a pattern without capture group returned %v", err)
+	}
+}
+
+func TestLinkRenderers(t *testing.T) {
+	cartridges := []Cartridge{{id: "HeroBanner"}, {id: "ProductGrid"}, {id: "Unused"}}
+	renderers := []Renderer{
+		{contentType: "HeroBanner", path: "WEB-INF/views/HeroBanner/HeroBanner.jsp"},
+		{contentType: "HeroBanner", path: "WEB-INF/views/HeroBanner/partials/slide.jsp"},
+		{contentType: "Legacy", path: "WEB-INF/views/Legacy/Legacy.jsp"},
+		{contentType: "ProductGrid", path: "src/components.js"},
+	}
+
+	linked, orphans := LinkRenderers(cartridges, renderers, true, false)
+	if paths := linked[0].GetRenderers(); len(paths) != 2 || paths[1] != "WEB-INF/views/HeroBanner/partials/slide.jsp" {
+		t.Errorf("HeroBanner has renderers %v", paths)
+	}
+	if paths := linked[1].GetRenderers(); len(paths) != 1 || paths[0] != "src/components.js" {
+		t.Errorf("ProductGrid has renderers %v", paths)
+	}
+	if paths := linked[2].GetRenderers(); len(paths) != 0 {
+		t.Errorf("Unused has renderers %v", paths)
+	}
+	if len(orphans) != 1 || orphans[0].GetContentType() != "Legacy" {
+		t.Errorf("LinkRenderers() returned orphans %+v", orphans)
+	}
+
+	b, err := json.Marshal(Application{OrphanRenderers: orphans})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `"orphanRenderers":[{"contentType":"Legacy","path":"WEB-INF/views/Legacy/Legacy.jsp"}]`; !strings.Contains(string(b), expected) {
+		t.Errorf("Application JSON %s doesn't contain %s", b, expected)
+	}
+}
@@ -0,0 +1,35 @@
+package endeca
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// applicationDirectories are the directories an application root holds
+var applicationDirectories = map[string]bool{"templates": true, "content": true, "pages": true}
+
+// ApplicationRoots returns the application roots among the slash separated paths of the
+// files of an export, e.g. the names in its zip. A root is the folder holding a templates,
+// content or pages directory, empty when they are at the top of the export. Exports zipped
+// with a wrapping folder have that folder as their root and exports of several
+// applications have several roots. The first of those directories in a path decides its
+// root, so a content rule named pages doesn't make a root of its own. macOS resource fork
+// folders are skipped.
+func ApplicationRoots(paths []string) []string {
+	var roots []string
+	for _, filePath := range paths {
+		segments := strings.Split(strings.Trim(path.Clean(filePath), "/"), "/")
+		for index, segment := range segments[:len(segments)-1] {
+			if segment == "__MACOSX" {
+				break
+			}
+			if applicationDirectories[segment] {
+				roots = appendUnique(roots, strings.Join(segments[:index], "/"))
+				break
+			}
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
@@ -0,0 +1,25 @@
+package endeca
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplicationRoots(t *testing.T) {
+	tests := []struct {
+		paths []string
+		roots []string
+	}{
+		{[]string{"templates/Banner/template.xml", "pages/Default/home/content.xml"}, []string{""}},
+		{[]string{"MyApp/", "MyApp/templates/", "MyApp/templates/Banner/template.xml"}, []string{"MyApp"}},
+		{[]string{"apps/A/pages/Default/content.xml", "apps/B/content/Shared/content.xml"}, []string{"apps/A", "apps/B"}},
+		{[]string{"content/Shared/pages/Rule/content.xml"}, []string{""}},
+		{[]string{"MyApp/templates/Banner/template.xml", "__MACOSX/MyApp/templates/Banner/._template.xml"}, []string{"MyApp"}},
+		{[]string{"README.txt", "docs/index.html"}, nil},
+	}
+	for _, test := range tests {
+		if roots := ApplicationRoots(test.paths); !reflect.DeepEqual(roots, test.roots) {
+			t.Errorf("ApplicationRoots(%q) = %q, expected %q", test.paths, roots, test.roots)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package endeca
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// siteDefinitionFile is the file Experience Manager keeps the site definition in
+const siteDefinitionFile = "_.json"
+
+// Site is a site defined under the pages directory of an Endeca application
+type Site struct {
+	// id is the directory name of the site under pages
+	id string
+	// displayName is the name of the site as shown in Experience Manager
+	displayName string
+	// urlPattern is the URL pattern that selects the site
+	urlPattern string
+	// defaultPage is the page served when no page is requested
+	defaultPage string
+	// filterState is the filter state applied to every query for the site
+	filterState map[string]interface{}
+	// pages defined in the site
+	pages []string
+}
+
+// siteDefinition is the JSON site definition found in pages/<site>/_.json
+type siteDefinition struct {
+	Type        string                 `json:"ecr:type"`
+	DisplayName string                 `json:"displayName"`
+	URLPattern  string                 `json:"urlPattern"`
+	DefaultPage string                 `json:"defaultPage"`
+	FilterState map[string]interface{} `json:"filterState"`
+}
+
+// MapSites reads the site definitions and the pages of every site for a given base path
+func MapSites(basePath string, DisableColor bool, Debug bool) []Site {
+	var sites []Site
+	var pagesPath = basePath + "/pages"
+
+	utils.DisplayInfo("Starting Endeca site scan.", DisableColor)
+	for _, siteID := range getCartridgePaths(pagesPath, DisableColor, Debug) {
+		site := getSiteDefinition(pagesPath+"/"+siteID, siteID, DisableColor, Debug)
+		site.pages = getSitePages(basePath, pagesPath+"/"+siteID, DisableColor, Debug)
+		sites = append(sites, site)
+	}
+	utils.DisplayInfo("Finished scanning Endeca sites.", DisableColor)
+	return sites
+}
+
+// getSiteDefinition reads the site definition of a site. If there is no definition
+// the site is still returned using its directory name as display name.
+func getSiteDefinition(sitePath string, siteID string, DisableColor bool, Debug bool) Site {
+	var site = Site{
+		id:          siteID,
+		displayName: siteID,
+	}
+
+	b, err := ioutil.ReadFile(sitePath + "/" + siteDefinitionFile)
+	if err != nil {
+		utils.DisplayWarning("Site definition not found for site "+siteID, DisableColor)
+		return site
+	}
+
+	var definition siteDefinition
+	if jsonErr := json.Unmarshal(b, &definition); jsonErr != nil {
+		utils.DisplayError("Couldn't read site definition for site "+siteID, jsonErr, DisableColor)
+		parseFailed()
+		return site
+	}
+	utils.DisplayDebug("Read site definition of type "+definition.Type+" for site "+siteID, Debug, DisableColor)
+
+	if strings.TrimSpace(definition.DisplayName) != "" {
+		site.displayName = definition.DisplayName
+	}
+	site.urlPattern = definition.URLPattern
+	site.defaultPage = definition.DefaultPage
+	site.filterState = definition.FilterState
+	return site
+}
+
+// getSitePages lists the pages of a site, a page being any directory holding a content.xml
+func getSitePages(basePath string, sitePath string, DisableColor bool, Debug bool) []string {
+	var pages []string
+	err := filepath.Walk(sitePath, func(path string, f os.FileInfo, walkError error) error {
+		if skip, skipError := skipIgnored(basePath, path, f); skip {
+			return skipError
+		}
+		if walkError == nil && isContentFile(f) {
+			pageName, relError := filepath.Rel(sitePath, filepath.Dir(path))
+			if relError == nil && pageName != "." {
+				pages = append(pages, filepath.ToSlash(pageName))
+			}
+		}
+		return walkError
+	})
+	if err != nil {
+		utils.DisplayError("Could not walk through site path "+sitePath, err, DisableColor)
+	}
+	sort.Strings(pages)
+	return pages
+}
+
+// sitePagePath splits the path of a content.xml under the pages directory into
+// the site it belongs to and the page name within that site
+func sitePagePath(pagesPath string, path string) (string, string) {
+	relPath, err := filepath.Rel(pagesPath, filepath.Dir(path))
+	if err != nil {
+		return "", ""
+	}
+	pathInfo := strings.SplitN(filepath.ToSlash(relPath), "/", 2)
+	if len(pathInfo) < 2 {
+		return pathInfo[0], ""
+	}
+	return pathInfo[0], pathInfo[1]
+}
+
+// SiteNames maps site IDs to their display names
+func SiteNames(sites []Site) map[string]string {
+	names := make(map[string]string)
+	for _, site := range sites {
+		names[site.id] = site.displayName
+	}
+	return names
+}
+
+// GetID returns the ID of the site
+func (s Site) GetID() string {
+	return s.id
+}
+
+// GetDisplayName returns the display name of the site
+func (s Site) GetDisplayName() string {
+	return s.displayName
+}
+
+// GetURLPattern returns the URL pattern of the site
+func (s Site) GetURLPattern() string {
+	return s.urlPattern
+}
+
+// GetDefaultPage returns the default page of the site
+func (s Site) GetDefaultPage() string {
+	return s.defaultPage
+}
+
+// GetFilterState returns the filter state of the site
+func (s Site) GetFilterState() map[string]interface{} {
+	return s.filterState
+}
+
+// GetPages returns the pages of the site
+func (s Site) GetPages() []string {
+	return s.pages
+}
@@ -0,0 +1,52 @@
+package endeca
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapSites(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "cartridgemapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(basePath)
+
+	writeTestFile(t, basePath+"/pages/Default/_.json",
+		`{"ecr:type":"site-home","displayName":"Default Store","urlPattern":"/store","defaultPage":"/home"}`)
+	writeTestFile(t, basePath+"/pages/Default/home/content.xml", `<ContentItem/>`)
+	writeTestFile(t, basePath+"/pages/Default/browse/tv/content.xml", `<ContentItem/>`)
+	writeTestFile(t, basePath+"/pages/Mobile/home/content.xml", `<ContentItem/>`)
+
+	sites := MapSites(basePath, true, false)
+	if len(sites) != 2 {
+		t.Fatalf("MapSites() returned %d sites, expected 2", len(sites))
+	}
+	if sites[0].GetDisplayName() != "Default Store" || sites[0].GetURLPattern() != "/store" {
+		t.Errorf("MapSites() didn't read the site definition: %+v", sites[0])
+	}
+	if pages := sites[0].GetPages(); len(pages) != 2 || pages[0] != "browse/tv" || pages[1] != "home" {
+		t.Errorf("MapSites() returned wrong pages %v", pages)
+	}
+	if sites[1].GetDisplayName() != "Mobile" {
+		t.Errorf("MapSites() should fall back to the site ID, got %s", sites[1].GetDisplayName())
+	}
+}
+
+func TestSitePagePath(t *testing.T) {
+	site, page := sitePagePath("base/pages", "base/pages/Default/browse/tv/content.xml")
+	if site != "Default" || page != "browse/tv" {
+		t.Errorf("sitePagePath() returned %s and %s", site, page)
+	}
+}
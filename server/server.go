@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/templates"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// apiPrefix is the path the JSON API is served under
+const apiPrefix = "/api/"
+
+// apiIndex lists the collections of the JSON API
+type apiIndex struct {
+	Cartridges int `json:"cartridges"`
+	Sites      int `json:"sites"`
+	Pages      int `json:"pages"`
+	Rules      int `json:"rules"`
+	Orphans    int `json:"orphans"`
+}
+
+// apiOrphans are the parts of the storefront that don't belong to any cartridge
+type apiOrphans struct {
+	Handlers  []endeca.Handler  `json:"handlers"`
+	Renderers []endeca.Renderer `json:"renderers"`
+}
+
+// apiError is the body of every failed API request
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// Serve serves the browser and JSON API for a mapped application on the given address
+func Serve(app endeca.Application, address string, DisableColor bool, Debug bool) error {
+	utils.DisplayInfo("Serving cartridge map at http://"+address+"/", DisableColor)
+	return http.ListenAndServe(address, NewHandler(app, DisableColor, Debug))
+}
+
+// NewHandler returns the HTTP handler serving the browser and the read-only JSON API.
+// The API serves:
+//
+//	/api/                  number of cartridges, sites, pages, rules and orphans
+//	/api/cartridges[/id]   cartridges
+//	/api/sites[/id]        sites
+//	/api/pages[/site/page] pages, filtered by site with ?site=
+//	/api/rules[/path]      content rules
+//	/api/orphans           handlers and renderers without a template
+func NewHandler(app endeca.Application, DisableColor bool, Debug bool) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(templates.BrowserPage))
+	})
+
+	mux.HandleFunc(apiPrefix, func(w http.ResponseWriter, r *http.Request) {
+		utils.DisplayDebug("Serving "+r.Method+" "+r.URL.Path, Debug, DisableColor)
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: "the API is read-only"}, DisableColor)
+			return
+		}
+
+		collection, key := splitAPIPath(r.URL.Path)
+		switch collection {
+		case "":
+			writeJSON(w, http.StatusOK, apiIndex{
+				Cartridges: len(app.Cartridges),
+				Sites:      len(app.Sites),
+				Pages:      len(app.Pages),
+				Rules:      len(app.Rules),
+				Orphans:    len(app.OrphanHandlers) + len(app.OrphanRenderers),
+			}, DisableColor)
+		case "cartridges":
+			if key == "" {
+				writeJSON(w, http.StatusOK, nonNilCartridges(app.Cartridges), DisableColor)
+			} else if cartridge, ok := app.FindCartridge(key); ok {
+				writeJSON(w, http.StatusOK, cartridge, DisableColor)
+			} else {
+				writeNotFound(w, "cartridge "+key, DisableColor)
+			}
+		case "sites":
+			if key == "" {
+				writeJSON(w, http.StatusOK, nonNilSites(app.Sites), DisableColor)
+			} else if site, ok := app.FindSite(key); ok {
+				writeJSON(w, http.StatusOK, site, DisableColor)
+			} else {
+				writeNotFound(w, "site "+key, DisableColor)
+			}
+		case "pages":
+			if key == "" {
+				writeJSON(w, http.StatusOK, filterPages(app.Pages, r.URL.Query().Get("site")), DisableColor)
+			} else if page, ok := app.FindPage(key); ok {
+				writeJSON(w, http.StatusOK, page, DisableColor)
+			} else {
+				writeNotFound(w, "page "+key, DisableColor)
+			}
+		case "rules":
+			if key == "" {
+				writeJSON(w, http.StatusOK, nonNilRules(app.Rules), DisableColor)
+			} else if rule, ok := app.FindRule(key); ok {
+				writeJSON(w, http.StatusOK, rule, DisableColor)
+			} else {
+				writeNotFound(w, "rule "+key, DisableColor)
+			}
+		case "orphans":
+			orphans := apiOrphans{Handlers: app.OrphanHandlers, Renderers: app.OrphanRenderers}
+			if orphans.Handlers == nil {
+				orphans.Handlers = []endeca.Handler{}
+			}
+			if orphans.Renderers == nil {
+				orphans.Renderers = []endeca.Renderer{}
+			}
+			writeJSON(w, http.StatusOK, orphans, DisableColor)
+		default:
+			writeNotFound(w, "collection "+collection, DisableColor)
+		}
+	})
+
+	return mux
+}
+
+// splitAPIPath splits an API path into the collection and the key within the collection.
+// Keys of pages and rules hold slashes themselves so everything after the collection is the key.
+func splitAPIPath(path string) (string, string) {
+	parts := strings.SplitN(strings.Trim(strings.TrimPrefix(path, apiPrefix), "/"), "/", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// filterPages returns the pages of a site, or all pages when no site is given
+func filterPages(pages []endeca.Page, siteID string) []endeca.Page {
+	filtered := []endeca.Page{}
+	for _, page := range pages {
+		if siteID == "" || page.GetSite() == siteID {
+			filtered = append(filtered, page)
+		}
+	}
+	return filtered
+}
+
+func nonNilCartridges(cartridges []endeca.Cartridge) []endeca.Cartridge {
+	if cartridges == nil {
+		return []endeca.Cartridge{}
+	}
+	return cartridges
+}
+
+func nonNilSites(sites []endeca.Site) []endeca.Site {
+	if sites == nil {
+		return []endeca.Site{}
+	}
+	return sites
+}
+
+func nonNilRules(rules []endeca.Rule) []endeca.Rule {
+	if rules == nil {
+		return []endeca.Rule{}
+	}
+	return rules
+}
+
+// writeNotFound writes a JSON 404 for the given thing
+func writeNotFound(w http.ResponseWriter, what string, DisableColor bool) {
+	writeJSON(w, http.StatusNotFound, apiError{Error: what + " not found"}, DisableColor)
+}
+
+// writeJSON writes value as indented JSON with the given status
+func writeJSON(w http.ResponseWriter, status int, value interface{}, DisableColor bool) {
+	b, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		utils.DisplayError("Couldn't encode API response.", err, DisableColor)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(b)
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+)
+
+func TestAPIIsReadOnly(t *testing.T) {
+	handler := NewHandler(endeca.Application{}, true, false)
+
+	for _, test := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/api/", http.StatusOK},
+		{http.MethodGet, "/api/cartridges", http.StatusOK},
+		{http.MethodGet, "/api/cartridges/Missing", http.StatusNotFound},
+		{http.MethodGet, "/api/unknown", http.StatusNotFound},
+		{http.MethodPost, "/api/cartridges", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/api/sites/Default", http.StatusMethodNotAllowed},
+	} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+		if recorder.Code != test.status {
+			t.Errorf("%s %s returned %d, expected %d", test.method, test.path, recorder.Code, test.status)
+		}
+	}
+}
+
+func TestSplitAPIPath(t *testing.T) {
+	collection, key := splitAPIPath("/api/pages/Default/browse/tv")
+	if collection != "pages" || key != "Default/browse/tv" {
+		t.Errorf("splitAPIPath() returned %s and %s", collection, key)
+	}
+}
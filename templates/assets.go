@@ -0,0 +1,147 @@
+package templates
+
+// The report used to pull Bootstrap, jQuery, Popper and DataTables from public CDNs which
+// left it unstyled on networks without internet access. These are small stand-ins for the
+// parts of them the reports use, inlined into every generated page so a report is one file.
+
+// ReportCSS styles the reports. It covers the Bootstrap classes used in the templates.
+var ReportCSS = `
+*, ::after, ::before { box-sizing: border-box; }
+body { margin: 0; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif; font-size: 1rem; line-height: 1.5; color: #212529; background-color: #fff; min-height: 75rem; }
+a { color: #007bff; text-decoration: none; }
+a:hover { color: #0056b3; text-decoration: underline; }
+h1, h2, h3, h4 { margin-top: 0; margin-bottom: .5rem; font-weight: 500; line-height: 1.2; }
+small, .small { font-size: 80%; }
+code { font-family: SFMono-Regular, Menlo, Monaco, Consolas, monospace; font-size: 87.5%; color: #e83e8c; }
+.container { width: 100%; max-width: 1140px; padding-right: 15px; padding-left: 15px; margin-right: auto; margin-left: auto; }
+.mb-4 { margin-bottom: 1.5rem; }
+.text-muted { color: #6c757d; }
+.navbar { position: relative; display: flex; flex-wrap: wrap; align-items: center; padding: .5rem 1rem; }
+.navbar-dark.bg-dark, .bg-dark { background-color: #343a40; }
+.navbar-brand { display: inline-block; padding-top: .3125rem; padding-bottom: .3125rem; margin-right: 1rem; font-size: 1.25rem; line-height: inherit; white-space: nowrap; color: #fff; }
+.navbar-brand:hover { color: #fff; text-decoration: none; }
+.navbar-toggler { display: none; }
+.navbar-collapse { display: flex; flex-basis: auto; flex-grow: 1; align-items: center; }
+.navbar-nav { display: flex; flex-direction: row; padding-left: 0; margin-bottom: 0; list-style: none; }
+.mr-auto { margin-right: auto; }
+.nav-link { display: block; padding: .5rem; }
+.navbar-dark .nav-link { color: rgba(255,255,255,.5); }
+.navbar-dark .nav-link:hover, .navbar-dark .active > .nav-link { color: #fff; text-decoration: none; }
+.sr-only { position: absolute; width: 1px; height: 1px; padding: 0; overflow: hidden; clip: rect(0,0,0,0); white-space: nowrap; border: 0; }
+.table { width: 100%; max-width: 100%; margin-bottom: 1rem; background-color: transparent; border-collapse: collapse; }
+.table td, .table th { padding: .75rem; vertical-align: top; border-top: 1px solid #e9ecef; text-align: left; }
+.table thead th { vertical-align: bottom; border-bottom: 2px solid #e9ecef; }
+.thead-inverse th { color: #fff; background-color: #212529; }
+.table tbody tr:hover { background-color: rgba(0,0,0,.035); }
+.table-controls { display: flex; justify-content: space-between; align-items: center; margin-bottom: .5rem; }
+.table-controls input, .table-controls select { padding: .25rem .5rem; font-size: .875rem; border: 1px solid #ced4da; border-radius: .2rem; }
+.table-info { color: #6c757d; font-size: .875rem; }
+.table-paging button { padding: .25rem .6rem; margin-left: .25rem; font-size: .875rem; border: 1px solid #dee2e6; background: #fff; color: #007bff; border-radius: .2rem; cursor: pointer; }
+.table-paging button[disabled] { color: #6c757d; cursor: default; }
+th.sortable { cursor: pointer; user-select: none; }
+th.sortable::after { content: " \2195"; opacity: .4; }
+th.sort-asc::after { content: " \2191"; opacity: 1; }
+th.sort-desc::after { content: " \2193"; opacity: 1; }
+`
+
+// ReportJS makes every table with the data-table class searchable, sortable and paged,
+// like the DataTables plugin did for the index page.
+var ReportJS = `
+(function () {
+  function cellText(row, index) {
+    var cell = row.cells[index];
+    return cell ? cell.textContent.replace(/\s+/g, " ").trim() : "";
+  }
+
+  function element(name, attributes, text) {
+    var node = document.createElement(name);
+    for (var attribute in attributes || {}) { node.setAttribute(attribute, attributes[attribute]); }
+    if (text !== undefined) { node.textContent = text; }
+    return node;
+  }
+
+  function dataTable(table) {
+    var body = table.tBodies[0];
+    if (!body) { return; }
+    var rows = Array.prototype.slice.call(body.rows);
+    var state = { query: "", sortColumn: -1, sortDirection: 1, page: 0, pageSize: 10 };
+
+    var controls = element("div", { "class": "table-controls" });
+    var sizeLabel = element("label", {}, "Show ");
+    var size = element("select");
+    [10, 25, 50, 100, -1].forEach(function (value) {
+      size.appendChild(element("option", { value: value }, value < 0 ? "All" : String(value)));
+    });
+    sizeLabel.appendChild(size);
+    sizeLabel.appendChild(document.createTextNode(" entries"));
+    var searchLabel = element("label", {}, "Search: ");
+    var search = element("input", { type: "search" });
+    searchLabel.appendChild(search);
+    controls.appendChild(sizeLabel);
+    controls.appendChild(searchLabel);
+    table.parentNode.insertBefore(controls, table);
+
+    var footer = element("div", { "class": "table-controls" });
+    var info = element("div", { "class": "table-info" });
+    var paging = element("div", { "class": "table-paging" });
+    var previous = element("button", { type: "button" }, "Previous");
+    var next = element("button", { type: "button" }, "Next");
+    paging.appendChild(previous);
+    paging.appendChild(next);
+    footer.appendChild(info);
+    footer.appendChild(paging);
+    table.parentNode.insertBefore(footer, table.nextSibling);
+
+    var headers = table.tHead ? table.tHead.rows[0].cells : [];
+    Array.prototype.forEach.call(headers, function (header, index) {
+      header.className += " sortable";
+      header.addEventListener("click", function () {
+        state.sortDirection = state.sortColumn === index ? -state.sortDirection : 1;
+        state.sortColumn = index;
+        Array.prototype.forEach.call(headers, function (other) {
+          other.className = other.className.replace(/ sort-(asc|desc)/g, "");
+        });
+        header.className += state.sortDirection > 0 ? " sort-asc" : " sort-desc";
+        draw();
+      });
+    });
+
+    function draw() {
+      var query = state.query.toLowerCase();
+      var shown = rows.filter(function (row) {
+        return row.textContent.toLowerCase().indexOf(query) >= 0;
+      });
+      if (state.sortColumn >= 0) {
+        shown.sort(function (a, b) {
+          var left = cellText(a, state.sortColumn), right = cellText(b, state.sortColumn);
+          return left.localeCompare(right, undefined, { numeric: true }) * state.sortDirection;
+        });
+      }
+      var pageSize = state.pageSize < 0 ? shown.length || 1 : state.pageSize;
+      var pages = Math.max(1, Math.ceil(shown.length / pageSize));
+      state.page = Math.min(state.page, pages - 1);
+      var start = state.page * pageSize;
+      var end = Math.min(start + pageSize, shown.length);
+
+      while (body.firstChild) { body.removeChild(body.firstChild); }
+      shown.slice(start, end).forEach(function (row) { body.appendChild(row); });
+
+      info.textContent = shown.length === 0 ? "No matching entries" :
+        "Showing " + (start + 1) + " to " + end + " of " + shown.length + " entries" +
+        (shown.length !== rows.length ? " (filtered from " + rows.length + " total entries)" : "");
+      previous.disabled = state.page === 0;
+      next.disabled = state.page >= pages - 1;
+    }
+
+    search.addEventListener("input", function () { state.query = search.value; state.page = 0; draw(); });
+    size.addEventListener("change", function () { state.pageSize = parseInt(size.value, 10); state.page = 0; draw(); });
+    previous.addEventListener("click", function () { state.page--; draw(); });
+    next.addEventListener("click", function () { state.page++; draw(); });
+    draw();
+  }
+
+  document.addEventListener("DOMContentLoaded", function () {
+    Array.prototype.forEach.call(document.querySelectorAll("table.data-table"), dataTable);
+  });
+})();
+`
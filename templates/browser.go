@@ -0,0 +1,207 @@
+package templates
+
+// BrowserPage is the interactive browser served by the serve command. It has no
+// template actions, everything is loaded from the JSON API.
+var BrowserPage = `
+<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1, shrink-to-fit=no">
+    <title>Endeca Cartridge Mapper</title>
+    <style>
+    body { margin: 0; font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; color: #212529; }
+    nav { background: #343a40; padding: .5rem 1rem; display: flex; align-items: center; }
+    nav .brand { color: #fff; font-size: 1.25rem; margin-right: 2rem; text-decoration: none; }
+    nav a.tab { color: rgba(255,255,255,.6); margin-right: 1rem; text-decoration: none; }
+    nav a.tab.active, nav a.tab:hover { color: #fff; }
+    main { padding: 1rem 2rem; }
+    input[type=search] { width: 100%; padding: .4rem; margin-bottom: 1rem; font-size: 1rem; box-sizing: border-box; }
+    table { border-collapse: collapse; width: 100%; }
+    th, td { text-align: left; vertical-align: top; padding: .5rem; border-top: 1px solid #dee2e6; }
+    th { background: #212529; color: #fff; cursor: pointer; user-select: none; }
+    tr:hover td { background: #f8f9fa; }
+    ul.values { list-style: none; margin: 0; padding: 0; }
+    .muted { color: #6c757d; }
+    pre { margin: 0; white-space: pre-wrap; }
+    </style>
+  </head>
+  <body>
+    <nav>
+      <a class="brand" href="#/cartridges">Endeca Cartridge Mapper</a>
+      <a class="tab" href="#/cartridges" data-kind="cartridges">Cartridges</a>
+      <a class="tab" href="#/sites" data-kind="sites">Sites</a>
+      <a class="tab" href="#/pages" data-kind="pages">Pages</a>
+      <a class="tab" href="#/rules" data-kind="rules">Rules</a>
+    </nav>
+    <main id="main"></main>
+
+    <script>
+    var kinds = {
+      cartridges: {
+        key: function (item) { return item.id; },
+        columns: [["ID", "id"], ["Description", "description"], ["Sites", "sites"], ["Pages", "pages"], ["Rules", "rules"]],
+        links: { sites: "sites", rules: "rules" }
+      },
+      sites: {
+        key: function (item) { return item.id; },
+        columns: [["ID", "id"], ["Name", "displayName"], ["URL pattern", "urlPattern"], ["Pages", "pages"]],
+        links: { pages: "pages" },
+        linkKey: { pages: function (item, value) { return item.id + "/" + value; } }
+      },
+      pages: {
+        key: function (item) { return item.key; },
+        columns: [["Site", "site"], ["Page", "name"], ["Cartridges", "cartridges"], ["Rules", "rules"]],
+        links: { site: "sites", cartridges: "cartridges", rules: "rules" }
+      },
+      rules: {
+        key: function (item) { return item.path; },
+        columns: [["Rule", "path"], ["Cartridges", "cartridges"], ["Pages", "pages"]],
+        links: { cartridges: "cartridges", pages: "pages" }
+      }
+    };
+    var cache = {};
+    var main = document.getElementById("main");
+
+    function api(path) {
+      return fetch("api/" + path).then(function (response) {
+        return response.json().then(function (body) {
+          if (!response.ok) { throw new Error(body.error || response.statusText); }
+          return body;
+        });
+      });
+    }
+
+    function element(name, text, attributes) {
+      var node = document.createElement(name);
+      if (text !== undefined && text !== null) { node.textContent = text; }
+      for (var attribute in attributes || {}) { node.setAttribute(attribute, attributes[attribute]); }
+      return node;
+    }
+
+    function link(kind, key, text) {
+      return element("a", text, { href: "#/" + kind + "/" + encodeURIComponent(key) });
+    }
+
+    function renderValue(kind, item, field) {
+      var value = item[field];
+      var target = kinds[kind].links[field];
+      var linkKey = (kinds[kind].linkKey || {})[field] || function (item, value) { return value; };
+      if (Array.isArray(value)) {
+        if (value.length === 0) { return element("span", "none", { "class": "muted" }); }
+        var list = element("ul", null, { "class": "values" });
+        value.forEach(function (entry) {
+          var li = element("li");
+          li.appendChild(target ? link(target, linkKey(item, entry), entry) : document.createTextNode(entry));
+          list.appendChild(li);
+        });
+        return list;
+      }
+      if (value !== null && typeof value === "object") {
+        return element("pre", JSON.stringify(value, null, 2));
+      }
+      if (field === kinds[kind].columns[0][1]) { return link(kind, kinds[kind].key(item), value); }
+      if (target) { return link(target, linkKey(item, value), value); }
+      return document.createTextNode(value === undefined ? "" : value);
+    }
+
+    function renderList(kind, items) {
+      var search = element("input", null, { type: "search", placeholder: "Filter " + kind + "..." });
+      var count = element("p", null, { "class": "muted" });
+      var table = element("table");
+      var head = element("tr");
+      var body = element("tbody");
+      var sortField = null, sortDirection = 1;
+
+      kinds[kind].columns.forEach(function (column) {
+        var th = element("th", column[0]);
+        th.addEventListener("click", function () {
+          sortDirection = sortField === column[1] ? -sortDirection : 1;
+          sortField = column[1];
+          draw();
+        });
+        head.appendChild(th);
+      });
+      var thead = element("thead");
+      thead.appendChild(head);
+      table.appendChild(thead);
+      table.appendChild(body);
+
+      function draw() {
+        var query = search.value.toLowerCase();
+        var shown = items.filter(function (item) {
+          return JSON.stringify(item).toLowerCase().indexOf(query) >= 0;
+        });
+        if (sortField) {
+          shown.sort(function (a, b) {
+            var left = String(a[sortField]), right = String(b[sortField]);
+            return left < right ? -sortDirection : left > right ? sortDirection : 0;
+          });
+        }
+        body.innerHTML = "";
+        shown.forEach(function (item) {
+          var tr = element("tr");
+          kinds[kind].columns.forEach(function (column) {
+            var td = element("td");
+            td.appendChild(renderValue(kind, item, column[1]));
+            tr.appendChild(td);
+          });
+          body.appendChild(tr);
+        });
+        count.textContent = shown.length + " of " + items.length + " " + kind;
+      }
+
+      search.addEventListener("input", draw);
+      main.innerHTML = "";
+      main.appendChild(search);
+      main.appendChild(count);
+      main.appendChild(table);
+      draw();
+      search.focus();
+    }
+
+    function renderDetail(kind, item) {
+      main.innerHTML = "";
+      var back = element("p");
+      back.appendChild(element("a", "← All " + kind, { href: "#/" + kind }));
+      main.appendChild(back);
+      main.appendChild(element("h2", kinds[kind].key(item)));
+      var table = element("table");
+      Object.keys(item).forEach(function (field) {
+        var tr = element("tr");
+        tr.appendChild(element("td", field, { "class": "muted" }));
+        var td = element("td");
+        td.appendChild(renderValue(kind, item, field));
+        tr.appendChild(td);
+        table.appendChild(tr);
+      });
+      main.appendChild(table);
+    }
+
+    function route() {
+      var parts = location.hash.replace(/^#\/?/, "").split("/");
+      var kind = kinds[parts[0]] ? parts[0] : "cartridges";
+      var key = decodeURIComponent(parts.slice(1).join("/"));
+      Array.prototype.forEach.call(document.querySelectorAll("nav a.tab"), function (tab) {
+        tab.className = tab.getAttribute("data-kind") === kind ? "tab active" : "tab";
+      });
+      var request = key ? api(kind + "/" + key.split("/").map(encodeURIComponent).join("/")) : (cache[kind] ? Promise.resolve(cache[kind]) : api(kind));
+      request.then(function (body) {
+        if (key) {
+          renderDetail(kind, body);
+        } else {
+          cache[kind] = body;
+          renderList(kind, body);
+        }
+      }).catch(function (error) {
+        main.innerHTML = "";
+        main.appendChild(element("p", error.message, { "class": "muted" }));
+      });
+    }
+
+    window.addEventListener("hashchange", route);
+    route();
+    </script>
+  </body>
+</html>
+`
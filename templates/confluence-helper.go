@@ -0,0 +1,49 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/xml"
+	"path/filepath"
+	"text/template"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// confluencePageData is what the ConfluencePage template is executed with
+type confluencePageData struct {
+	App endeca.Application
+}
+
+// CartridgeOutputConfluence receives the mapped application and by using the ConfluencePage
+// template writes it in Confluence storage format to cartridges.xhtml, ready to be pasted
+// into the source editor or pushed through the Confluence REST API.
+func CartridgeOutputConfluence(app endeca.Application, outputPath string, DisableColor bool, Debug bool) error {
+	siteNames := endeca.SiteNames(app.Sites)
+	funcMap := template.FuncMap{
+		"xml": func(value string) string {
+			var buf bytes.Buffer
+			xml.EscapeText(&buf, []byte(value))
+			return buf.String()
+		},
+		"siteName": func(siteID string) string {
+			if name, ok := siteNames[siteID]; ok {
+				return name
+			}
+			return siteID
+		},
+		"pagesUsing": app.PagesUsing,
+	}
+	t, parseFileError := template.New("ConfluencePage").Funcs(funcMap).Parse(ConfluencePage)
+	if parseFileError != nil {
+		utils.DisplayError("Had a parsefile error", parseFileError, DisableColor)
+		return parseFileError
+	}
+
+	if err := writeTextFile(t, "index", filepath.Join(outputPath, "cartridges.xhtml"), confluencePageData{App: app}); err != nil {
+		utils.DisplayError("Couldn't write cartridges.xhtml", err, DisableColor)
+		return err
+	}
+	utils.DisplayInfo("Created cartridges.xhtml file at "+outputPath+"/cartridges.xhtml", DisableColor)
+	return nil
+}
@@ -0,0 +1,97 @@
+package templates
+
+// ConfluencePage is the Confluence storage format template. It is a text template so
+// every value has to go through xml.
+var ConfluencePage = `
+{{- define "index" -}}
+<h1>Endeca Cartridge Map</h1>
+<table>
+<tbody>
+<tr><th>Cartridge</th><th>Description</th><th>Sites</th><th>Pages</th><th>Rules</th></tr>
+{{- range .App.Cartridges }}
+<tr>
+<td><ac:link ac:anchor="{{ xml .GetID }}"><ac:link-body>{{ xml .GetID }}</ac:link-body></ac:link></td>
+<td>{{ xml .GetDescription }}</td>
+<td>{{ range $i, $site := .GetSites }}{{ if $i }}<br />{{ end }}{{ xml (siteName $site) }}{{ else }}<em>none</em>{{ end }}</td>
+<td>{{ len (pagesUsing .GetID) }}</td>
+<td>{{ len .GetRules }}</td>
+</tr>
+{{- end }}
+</tbody>
+</table>
+{{- if .App.OrphanHandlers }}
+<h2>Handlers without a template</h2>
+<table>
+<tbody>
+<tr><th>Bean</th><th>Content item type</th><th>Class</th></tr>
+{{- range .App.OrphanHandlers }}
+<tr><td><code>{{ xml .GetBeanID }}</code></td><td>{{ xml .GetContentType }}</td><td><code>{{ xml .GetClassName }}</code></td></tr>
+{{- end }}
+</tbody>
+</table>
+{{- end }}
+{{- if .App.OrphanRenderers }}
+<h2>Renderers without a template</h2>
+<table>
+<tbody>
+<tr><th>Content item type</th><th>Renderer</th></tr>
+{{- range .App.OrphanRenderers }}
+<tr><td>{{ xml .GetContentType }}</td><td><code>{{ xml .GetPath }}</code></td></tr>
+{{- end }}
+</tbody>
+</table>
+{{- end }}
+{{- range .App.Cartridges }}
+<h2><ac:structured-macro ac:name="anchor"><ac:parameter ac:name="">{{ xml .GetID }}</ac:parameter></ac:structured-macro>{{ xml .GetID }}</h2>
+<p>{{ xml .GetDescription }}</p>
+<table>
+<tbody>
+<tr><th>ID</th><td><code>{{ xml .GetID }}</code></td></tr>
+<tr><th>Template type</th><td>{{ xml .GetTemplateType }}</td></tr>
+{{- range $locale, $description := .GetDescriptions }}
+<tr><th>Description ({{ xml $locale }})</th><td>{{ xml $description }}</td></tr>
+{{- end }}
+{{- with .GetHandler }}
+<tr><th>Handler</th><td><code>{{ xml .GetClassName }}</code> ({{ xml .GetBeanID }})</td></tr>
+{{- end }}
+{{- if .GetRenderers }}
+<tr><th>Renderers</th><td>{{ range $i, $renderer := .GetRenderers }}{{ if $i }}<br />{{ end }}<code>{{ xml $renderer }}</code>{{ end }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+<h3>Properties</h3>
+{{- if .GetProperties }}
+<table>
+<tbody>
+<tr><th>Property</th><th>Type</th></tr>
+{{- range .GetProperties }}
+<tr><td><code>{{ xml .GetName }}</code></td><td>{{ xml .GetType }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+{{- else }}
+<p><em>The template defines no properties.</em></p>
+{{- end }}
+<h3>Rules</h3>
+{{- if .GetRules }}
+<ul>
+{{- range .GetRules }}
+<li><code>{{ xml . }}</code></li>
+{{- end }}
+</ul>
+{{- else }}
+<p><em>No Rule found</em></p>
+{{- end }}
+<h3>Pages</h3>
+{{- with pagesUsing .GetID }}
+<ul>
+{{- range . }}
+<li>{{ xml (siteName .GetSite) }}: <code>{{ xml .GetName }}</code></li>
+{{- end }}
+</ul>
+{{- else }}
+<p><em>Cartridge not used on any page</em></p>
+{{- end }}
+{{- end }}
+{{ end }}
+`
@@ -0,0 +1,211 @@
+package templates
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// sarifSchema is the JSON schema of the SARIF version written
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// FindingsOutputSARIF writes the validation findings as a SARIF 2.1.0 log. Locations are
+// relative to the root of the export, prefixed with locationPrefix when the export lives in
+// a sub directory of the repository being scanned.
+func FindingsOutputSARIF(findings []endeca.Finding, locationPrefix string, outputPath string, DisableColor bool, Debug bool) error {
+	driver := sarifDriver{Name: "cartridgemapper", InformationURI: "https://github.com/johnroach/cartridgemapper"}
+	ruleIndex := make(map[string]int)
+	for index, rule := range endeca.FindingRules {
+		ruleIndex[rule.ID] = index
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{Level: rule.Severity},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, finding := range findings {
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: findingLocation(locationPrefix, finding)}}
+		if finding.GetLine() > 0 {
+			location.Region = &sarifRegion{StartLine: finding.GetLine()}
+		}
+		results = append(results, sarifResult{
+			RuleID:    finding.GetRuleID(),
+			RuleIndex: ruleIndex[finding.GetRuleID()],
+			Level:     finding.GetSeverity(),
+			Message:   sarifMessage{Text: finding.GetMessage()},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	b, err := json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}, "", "  ")
+	if err != nil {
+		utils.DisplayError("Couldn't create SARIF log", err, DisableColor)
+		return err
+	}
+	return writeFindingsFile(b, "findings.sarif", outputPath, DisableColor)
+}
+
+// FindingsOutputJUnit writes the validation findings as a JUnit XML report with a test
+// suite per finding rule and a failed test case per finding. Rules without findings get
+// a single passing test case so CI shows which checks ran.
+func FindingsOutputJUnit(findings []endeca.Finding, locationPrefix string, outputPath string, DisableColor bool, Debug bool) error {
+	report := junitTestSuites{Name: "cartridgemapper"}
+	for _, rule := range endeca.FindingRules {
+		suite := junitTestSuite{Name: rule.ID}
+		for _, finding := range findings {
+			if finding.GetRuleID() != rule.ID {
+				continue
+			}
+			location := findingLocation(locationPrefix, finding)
+			name := location
+			if finding.GetLine() > 0 {
+				name += ":" + strconv.Itoa(finding.GetLine())
+			}
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      name,
+				ClassName: rule.ID,
+				File:      location,
+				Line:      finding.GetLine(),
+				Failure: &junitFailure{
+					Message: finding.GetMessage(),
+					Type:    finding.GetSeverity(),
+					Text:    name + ": " + finding.GetMessage(),
+				},
+			})
+			suite.Failures++
+		}
+		if len(suite.TestCases) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: rule.Description, ClassName: rule.ID})
+		}
+		suite.Tests = len(suite.TestCases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+	}
+
+	b, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		utils.DisplayError("Couldn't create JUnit report", err, DisableColor)
+		return err
+	}
+	return writeFindingsFile(append([]byte(xml.Header), b...), "findings.junit.xml", outputPath, DisableColor)
+}
+
+// findingLocation returns the path of the file a finding is in, as it is reported
+func findingLocation(locationPrefix string, finding endeca.Finding) string {
+	if locationPrefix == "" {
+		return finding.GetPath()
+	}
+	return filepath.ToSlash(filepath.Join(locationPrefix, finding.GetPath()))
+}
+
+// writeFindingsFile writes a findings report to the output path
+func writeFindingsFile(b []byte, name string, outputPath string, DisableColor bool) error {
+	path := filepath.Join(outputPath, name)
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		utils.DisplayError("Couldn't write "+path, err, DisableColor)
+		return err
+	}
+	utils.DisplayInfo("Created "+name+" file at "+path, DisableColor)
+	return nil
+}
@@ -0,0 +1,176 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"path/filepath"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// chartColors are the colors of the lines of a chart, in order
+var chartColors = []string{"#007bff", "#28a745", "#dc3545", "#ffc107", "#17a2b8", "#6f42c1"}
+
+// historyPageData is what the HistoryPage template is executed with
+type historyPageData struct {
+	Apps []historyApp
+	CSS  template.CSS
+	JS   template.JS
+}
+
+// historyApp are the snapshots of one application and the trends across them
+type historyApp struct {
+	Name      string
+	Snapshots []endeca.Snapshot
+	Trends    []endeca.CartridgeTrend
+}
+
+// chartSeries is a named line of a chart
+type chartSeries struct {
+	Name   string
+	Values []int
+}
+
+// HistoryOutputHTML writes the usage trends of the snapshots as an HTML page of charts, a
+// section per application
+func HistoryOutputHTML(snapshots []endeca.Snapshot, path string, DisableColor bool, Debug bool) error {
+	var apps []historyApp
+	appIndex := make(map[string]int)
+	for _, snapshot := range snapshots {
+		index, ok := appIndex[snapshot.App]
+		if !ok {
+			index = len(apps)
+			appIndex[snapshot.App] = index
+			apps = append(apps, historyApp{Name: snapshot.App})
+		}
+		apps[index].Snapshots = append(apps[index].Snapshots, snapshot)
+	}
+	for index := range apps {
+		apps[index].Trends = endeca.UsageTrends(apps[index].Snapshots)
+	}
+
+	funcMap := template.FuncMap{
+		"totalsChart": totalsChart,
+		"sparkline":   sparkline,
+		"lastSnapshot": func(snapshots []endeca.Snapshot) endeca.Snapshot {
+			return snapshots[len(snapshots)-1]
+		},
+		"lastUsage": func(usage []endeca.CartridgeUsage) endeca.CartridgeUsage {
+			return usage[len(usage)-1]
+		},
+		"change": func(usage []endeca.CartridgeUsage) string {
+			return fmt.Sprintf("%+d", usage[len(usage)-1].Pages-usage[0].Pages)
+		},
+	}
+	t, parseFileError := template.New("HistoryPage").Funcs(funcMap).Parse(HistoryPage)
+	if parseFileError != nil {
+		utils.DisplayError("Had a parsefile error", parseFileError, DisableColor)
+		return parseFileError
+	}
+	if err := writeTextFile(t, "HistoryPage", path, historyPageData{
+		Apps: apps,
+		CSS:  template.CSS(ReportCSS),
+		JS:   template.JS(ReportJS),
+	}); err != nil {
+		utils.DisplayError("Couldn't write "+filepath.Base(path), err, DisableColor)
+		return err
+	}
+	utils.DisplayInfo("Created trend charts at "+path, DisableColor)
+	return nil
+}
+
+// snapshotLabels returns the label of every snapshot, its ID when it has no label
+func snapshotLabels(snapshots []endeca.Snapshot) []string {
+	var labels []string
+	for _, snapshot := range snapshots {
+		if snapshot.Label != "" {
+			labels = append(labels, snapshot.Label)
+		} else {
+			labels = append(labels, snapshot.ID)
+		}
+	}
+	return labels
+}
+
+// totalsChart charts the number of cartridges and of cartridges in use across the snapshots
+func totalsChart(snapshots []endeca.Snapshot) template.HTML {
+	cartridges := chartSeries{Name: "Cartridges"}
+	used := chartSeries{Name: "Used on pages or rules"}
+	for _, snapshot := range snapshots {
+		var usedCount int
+		for _, usage := range snapshot.Usage {
+			if usage.Pages > 0 || usage.Rules > 0 {
+				usedCount++
+			}
+		}
+		cartridges.Values = append(cartridges.Values, len(snapshot.Usage))
+		used.Values = append(used.Values, usedCount)
+	}
+	return lineChart([]chartSeries{cartridges, used}, snapshotLabels(snapshots), 720, 240)
+}
+
+// sparkline charts the number of pages using a cartridge across the snapshots
+func sparkline(usage []endeca.CartridgeUsage) template.HTML {
+	pages := chartSeries{Name: "Pages"}
+	for _, snapshotUsage := range usage {
+		pages.Values = append(pages.Values, snapshotUsage.Pages)
+	}
+	return lineChart([]chartSeries{pages}, nil, 160, 32)
+}
+
+// lineChart draws the series as an inline SVG line chart. Charts with labels get axes, a
+// legend and a label per point, charts without them are sparklines.
+func lineChart(series []chartSeries, labels []string, width int, height int) template.HTML {
+	padding := 4
+	if labels != nil {
+		padding = 32
+	}
+	max := 1
+	points := 0
+	for _, line := range series {
+		for _, value := range line.Values {
+			if value > max {
+				max = value
+			}
+		}
+		if len(line.Values) > points {
+			points = len(line.Values)
+		}
+	}
+	x := func(index int) float64 {
+		if points < 2 {
+			return float64(width) / 2
+		}
+		return float64(padding) + float64(index)*float64(width-2*padding)/float64(points-1)
+	}
+	y := func(value int) float64 {
+		return float64(height-padding) - float64(value)*float64(height-2*padding)/float64(max)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg class="chart" width="%d" height="%d" viewBox="0 0 %d %d" role="img">`, width, height, width, height)
+	if labels != nil {
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#ccc"/>`, padding, height-padding, width-padding, height-padding)
+		fmt.Fprintf(&b, `<text x="2" y="%d" font-size="10">%d</text>`, padding, max)
+		for index, label := range labels {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">%s</text>`, x(index), height-padding+14, html.EscapeString(label))
+		}
+	}
+	for index, line := range series {
+		color := chartColors[index%len(chartColors)]
+		var coordinates bytes.Buffer
+		for point, value := range line.Values {
+			fmt.Fprintf(&coordinates, "%.1f,%.1f ", x(point), y(value))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"><title>%s</title></polyline>`,
+			color, bytes.TrimSpace(coordinates.Bytes()), html.EscapeString(line.Name))
+		if labels != nil {
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" fill="%s">%s</text>`, padding+index*180, 12, color, html.EscapeString(line.Name))
+		}
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,86 @@
+package templates
+
+// HistoryPage is the HTML page of cartridge usage trends across the snapshots of the
+// history, a section per application
+var HistoryPage = `
+<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1, shrink-to-fit=no">
+
+    <title>Endeca Cartridge History</title>
+
+    <!-- Report styles, inlined so the report works offline -->
+    <style>{{ .CSS }}</style>
+  </head>
+  <body>
+
+    <nav class="navbar navbar-expand-md navbar-dark bg-dark mb-4">
+      <a class="navbar-brand" href="#">Endeca Cartridge History</a>
+    </nav>
+
+    <div class="container">
+      {{- range $index, $app := .Apps }}
+      <h1 id="app-{{ $index }}">{{ $app.Name }}</h1>
+      <p>{{ len $app.Snapshots }} snapshots from {{ (index $app.Snapshots 0).Time.Format "2006-01-02 15:04" }} to {{ (lastSnapshot $app.Snapshots).Time.Format "2006-01-02 15:04" }}.</p>
+      {{ totalsChart $app.Snapshots }}
+
+      <h2>Cartridge Usage</h2>
+      <table class="table data-table">
+        <thead class="thead-inverse">
+          <tr>
+            <th>Cartridge</th>
+            <th>Pages over time</th>
+            <th>Pages</th>
+            <th>Sites</th>
+            <th>Rules</th>
+            <th>Pages since first snapshot</th>
+          </tr>
+        </thead>
+        <tbody>
+          {{- range $app.Trends }}
+          {{- $last := lastUsage .Usage }}
+          <tr>
+            <td>{{ .ID }}</td>
+            <td>{{ sparkline .Usage }}</td>
+            <td>{{ $last.Pages }}</td>
+            <td>{{ $last.Sites }}</td>
+            <td>{{ $last.Rules }}</td>
+            <td>{{ change .Usage }}</td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+
+      <h2>Snapshots</h2>
+      <table class="table table-sm">
+        <thead>
+          <tr>
+            <th>ID</th>
+            <th>Time</th>
+            <th>Label</th>
+            <th>Cartridges</th>
+          </tr>
+        </thead>
+        <tbody>
+          {{- range $app.Snapshots }}
+          <tr>
+            <td><code>{{ .ID }}</code></td>
+            <td>{{ .Time.Format "2006-01-02 15:04:05" }}</td>
+            <td>{{ .Label }}</td>
+            <td>{{ len .Usage }}</td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- else }}
+      <p>The history has no snapshots yet.</p>
+      {{- end }}
+    </div>
+
+    <!-- Searchable, sortable and paged table, inlined so the report works offline -->
+    <script>{{ .JS }}</script>
+  </body>
+</html>
+`
@@ -0,0 +1,133 @@
+package templates
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// markdownEscaper escapes the characters that would otherwise be read as Markdown
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`",
+	"[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;", "#", `\#`,
+	"\r\n", " ", "\n", " ",
+)
+
+// markdownIndexData is what the Markdown index template is executed with
+type markdownIndexData struct {
+	App   endeca.Application
+	Split bool
+}
+
+// markdownCartridgeData is what the Markdown cartridge template is executed with
+type markdownCartridgeData struct {
+	Cartridge endeca.Cartridge
+	Level     int
+}
+
+// CartridgeOutputMarkdown receives the mapped application and by using the MarkdownPages
+// templates writes it as GitHub flavored Markdown. Everything goes into cartridges.md
+// unless split is set, in which case README.md links to a file per cartridge.
+func CartridgeOutputMarkdown(app endeca.Application, split bool, outputPath string, DisableColor bool, Debug bool) error {
+	siteNames := endeca.SiteNames(app.Sites)
+	funcMap := template.FuncMap{
+		"md":   markdownEscaper.Replace,
+		"code": markdownCode,
+		"heading": func(level int) string {
+			return strings.Repeat("#", level)
+		},
+		"inc": func(level int) int {
+			return level + 1
+		},
+		"siteName": func(siteID string) string {
+			if name, ok := siteNames[siteID]; ok {
+				return name
+			}
+			return siteID
+		},
+		"pagesUsing": app.PagesUsing,
+		"cartridgeLink": func(cartridgeID string) string {
+			if split {
+				return "cartridges/" + slug(cartridgeID) + ".md"
+			}
+			return "#" + markdownAnchor(cartridgeID)
+		},
+		"cartridge": func(cartridge endeca.Cartridge, level int) markdownCartridgeData {
+			return markdownCartridgeData{Cartridge: cartridge, Level: level}
+		},
+	}
+	t, parseFileError := template.New("MarkdownPages").Funcs(funcMap).Parse(MarkdownPages)
+	if parseFileError != nil {
+		utils.DisplayError("Had a parsefile error", parseFileError, DisableColor)
+		return parseFileError
+	}
+
+	indexFile := "cartridges.md"
+	if split {
+		indexFile = "README.md"
+	}
+	if err := writeTextFile(t, "index", filepath.Join(outputPath, indexFile), markdownIndexData{App: app, Split: split}); err != nil {
+		utils.DisplayError("Couldn't write "+indexFile, err, DisableColor)
+		return err
+	}
+
+	if split {
+		for _, cartridge := range app.Cartridges {
+			cartridgeFile := filepath.Join(outputPath, "cartridges", slug(cartridge.GetID())+".md")
+			if err := writeTextFile(t, "cartridge", cartridgeFile, markdownCartridgeData{Cartridge: cartridge, Level: 1}); err != nil {
+				utils.DisplayError("Couldn't write "+cartridgeFile, err, DisableColor)
+				return err
+			}
+			utils.DisplayDebug("Created "+cartridgeFile, Debug, DisableColor)
+		}
+	}
+	utils.DisplayInfo("Created "+indexFile+" file at "+outputPath+"/"+indexFile, DisableColor)
+	return nil
+}
+
+// templateExecutor is a text or HTML template
+type templateExecutor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// writeTextFile executes the named template into a file, creating its directory
+func writeTextFile(t templateExecutor, name string, path string, data interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	fo, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+	return t.ExecuteTemplate(fo, name, data)
+}
+
+// markdownCode wraps a value in a code span, using a longer fence when it holds backticks
+func markdownCode(value string) string {
+	value = strings.Replace(value, "|", `\|`, -1)
+	if strings.Contains(value, "`") {
+		return "`` " + value + " ``"
+	}
+	return "`" + value + "`"
+}
+
+// markdownAnchor returns the anchor GitHub generates for a heading
+func markdownAnchor(heading string) string {
+	var anchor []rune
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			anchor = append(anchor, r)
+		case r == ' ':
+			anchor = append(anchor, '-')
+		}
+	}
+	return string(anchor)
+}
@@ -0,0 +1,23 @@
+package templates
+
+import (
+	"testing"
+)
+
+func TestMarkdownEscaping(t *testing.T) {
+	if escaped := markdownEscaper.Replace("a|b *c*\nd"); escaped != `a\|b \*c\* d` {
+		t.Errorf("markdownEscaper escaped to %s", escaped)
+	}
+	if code := markdownCode("a|b"); code != "`a\\|b`" {
+		t.Errorf("markdownCode() returned %s", code)
+	}
+	if code := markdownCode("a`b"); code != "`` a`b ``" {
+		t.Errorf("markdownCode() returned %s", code)
+	}
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	if anchor := markdownAnchor("Hero Banner (v2)"); anchor != "hero-banner-v2" {
+		t.Errorf("markdownAnchor() returned %s", anchor)
+	}
+}
@@ -0,0 +1,83 @@
+package templates
+
+// MarkdownPages are the GitHub flavored Markdown templates. They are text templates so
+// every value has to go through md (or code) to keep tables and emphasis intact.
+var MarkdownPages = `
+{{- define "index" -}}
+# Endeca Cartridge Map
+
+| Cartridge | Description | Sites | Pages | Rules |
+| --- | --- | --- | --- | --- |
+{{- range .App.Cartridges }}
+| [{{ md .GetID }}]({{ cartridgeLink .GetID }}) | {{ md .GetDescription }} | {{ range $i, $site := .GetSites }}{{ if $i }}, {{ end }}{{ md (siteName $site) }}{{ else }}_none_{{ end }} | {{ len (pagesUsing .GetID) }} | {{ len .GetRules }} |
+{{- end }}
+{{- if .App.OrphanHandlers }}
+
+## Handlers without a template
+
+| Bean | Content item type | Class |
+| --- | --- | --- |
+{{- range .App.OrphanHandlers }}
+| {{ code .GetBeanID }} | {{ md .GetContentType }} | {{ code .GetClassName }} |
+{{- end }}
+{{- end }}
+{{- if .App.OrphanRenderers }}
+
+## Renderers without a template
+
+| Content item type | Renderer |
+| --- | --- |
+{{- range .App.OrphanRenderers }}
+| {{ md .GetContentType }} | {{ code .GetPath }} |
+{{- end }}
+{{- end }}
+{{ if not .Split }}
+{{- range .App.Cartridges }}
+{{ template "cartridge" (cartridge . 2) }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "cartridge" }}
+{{ heading .Level }} {{ md .Cartridge.GetID }}
+
+{{ md .Cartridge.GetDescription }}
+
+- **ID:** {{ code .Cartridge.GetID }}
+- **Template type:** {{ md .Cartridge.GetTemplateType }}
+{{- range $locale, $description := .Cartridge.GetDescriptions }}
+- **Description ({{ md $locale }}):** {{ md $description }}
+{{- end }}
+{{- with .Cartridge.GetHandler }}
+- **Handler:** {{ code .GetClassName }} ({{ code .GetBeanID }})
+{{- end }}
+{{- range .Cartridge.GetRenderers }}
+- **Renderer:** {{ code . }}
+{{- end }}
+
+{{ heading (inc .Level) }} Properties
+{{ if .Cartridge.GetProperties }}
+| Property | Type |
+| --- | --- |
+{{- range .Cartridge.GetProperties }}
+| {{ code .GetName }} | {{ md .GetType }} |
+{{- end }}
+{{- else }}
+_The template defines no properties._
+{{- end }}
+
+{{ heading (inc .Level) }} Rules
+{{ range .Cartridge.GetRules }}
+- {{ code . }}
+{{- else }}
+_No Rule found_
+{{- end }}
+
+{{ heading (inc .Level) }} Pages
+{{ range pagesUsing .Cartridge.GetID }}
+- {{ md (siteName .GetSite) }}: {{ code .GetName }}
+{{- else }}
+_Cartridge not used on any page_
+{{- end }}
+{{ end }}
+`
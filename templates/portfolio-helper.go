@@ -0,0 +1,75 @@
+package templates
+
+import (
+	htmltemplate "html/template"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// portfolioPageData is what the portfolio templates are executed with
+type portfolioPageData struct {
+	Portfolio endeca.Portfolio
+	// Reports are the links to the report of every application, relative to the portfolio
+	Reports []string
+	CSS     htmltemplate.CSS
+	JS      htmltemplate.JS
+}
+
+// variantLabel shows the definition number of a cartridge in an application as a letter,
+// a dash when the application doesn't define it
+func variantLabel(variant int) string {
+	if variant <= 0 {
+		return "-"
+	}
+	if variant > 26 {
+		return strconv.Itoa(variant)
+	}
+	return string(rune('A' + variant - 1))
+}
+
+// PortfolioOutputHTML writes the portfolio report of several applications as portfolio.html,
+// linking to the report of every application
+func PortfolioOutputHTML(portfolio endeca.Portfolio, reports []string, outputPath string, DisableColor bool, Debug bool) error {
+	t, parseFileError := htmltemplate.New("PortfolioPage").Funcs(htmltemplate.FuncMap{"variant": variantLabel}).Parse(PortfolioPage)
+	if parseFileError != nil {
+		utils.DisplayError("Had a parsefile error", parseFileError, DisableColor)
+		return parseFileError
+	}
+	path := filepath.Join(outputPath, "portfolio.html")
+	if err := writeTextFile(t, "PortfolioPage", path, portfolioPageData{
+		Portfolio: portfolio,
+		Reports:   reports,
+		CSS:       htmltemplate.CSS(ReportCSS),
+		JS:        htmltemplate.JS(ReportJS),
+	}); err != nil {
+		utils.DisplayError("Couldn't write portfolio.html", err, DisableColor)
+		return err
+	}
+	utils.DisplayInfo("Created portfolio.html file at "+path, DisableColor)
+	return nil
+}
+
+// PortfolioOutputMarkdown writes the portfolio report of several applications as
+// GitHub flavored Markdown in portfolio.md, linking to the report of every application
+func PortfolioOutputMarkdown(portfolio endeca.Portfolio, reports []string, outputPath string, DisableColor bool, Debug bool) error {
+	funcMap := template.FuncMap{
+		"md":      markdownEscaper.Replace,
+		"variant": variantLabel,
+	}
+	t, parseFileError := template.New("PortfolioMarkdown").Funcs(funcMap).Parse(PortfolioMarkdown)
+	if parseFileError != nil {
+		utils.DisplayError("Had a parsefile error", parseFileError, DisableColor)
+		return parseFileError
+	}
+	path := filepath.Join(outputPath, "portfolio.md")
+	if err := writeTextFile(t, "portfolio", path, portfolioPageData{Portfolio: portfolio, Reports: reports}); err != nil {
+		utils.DisplayError("Couldn't write portfolio.md", err, DisableColor)
+		return err
+	}
+	utils.DisplayInfo("Created portfolio.md file at "+path, DisableColor)
+	return nil
+}
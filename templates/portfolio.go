@@ -0,0 +1,107 @@
+package templates
+
+// PortfolioPage is the HTML portfolio report of several applications: a section per
+// application and a matrix of the cartridge IDs across them
+var PortfolioPage = `
+<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1, shrink-to-fit=no">
+
+    <title>Endeca Cartridge Portfolio</title>
+
+    <!-- Report styles, inlined so the report works offline -->
+    <style>{{ .CSS }}</style>
+  </head>
+  <body>
+
+    <nav class="navbar navbar-expand-md navbar-dark bg-dark mb-4">
+      <a class="navbar-brand" href="#">Endeca Cartridge Portfolio</a>
+    </nav>
+
+    <div class="container">
+      <h1>Applications</h1>
+      <p>
+        {{ len .Portfolio.Cartridges }} cartridge IDs across {{ len .Portfolio.Apps }} applications:
+        {{ .Portfolio.StatusCount "shared" }} shared, {{ .Portfolio.StatusCount "diverged" }} diverged
+        and {{ .Portfolio.StatusCount "unique" }} unique.
+      </p>
+      {{- range $index, $app := .Portfolio.Apps }}
+      <h2 id="app-{{ $index }}">{{ $app.Name }}</h2>
+      <table class="table table-sm">
+        <tbody>
+          <tr><th>Report</th><td><a href="{{ index $.Reports $index }}">{{ index $.Reports $index }}</a></td></tr>
+          <tr><th>Cartridges</th><td>{{ len $app.App.Cartridges }}</td></tr>
+          <tr><th>Unique cartridges</th><td>{{ $.Portfolio.UniqueCount $index }}</td></tr>
+          <tr><th>Sites</th><td>{{ len $app.App.Sites }}</td></tr>
+          <tr><th>Pages</th><td>{{ len $app.App.Pages }}</td></tr>
+          <tr><th>Rules</th><td>{{ len $app.App.Rules }}</td></tr>
+        </tbody>
+      </table>
+      {{- end }}
+
+      <h1>Cartridge Matrix</h1>
+      <p>
+        A cartridge is shared when the applications defining it agree on its template type,
+        description and properties and diverged when they don't. Letters mark the definitions,
+        applications with the same letter define the cartridge the same way.
+      </p>
+      <table class="table data-table" id="matrix">
+        <thead class="thead-inverse">
+          <tr>
+            <th>Cartridge</th>
+            <th>Status</th>
+            {{- range .Portfolio.Apps }}
+            <th>{{ .Name }}</th>
+            {{- end }}
+          </tr>
+        </thead>
+        <tbody>
+          {{- range .Portfolio.Cartridges }}
+          <tr>
+            <td>{{ .ID }}</td>
+            <td>{{ .Status }}</td>
+            {{- range .Variants }}
+            <td>{{ variant . }}</td>
+            {{- end }}
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+    </div>
+
+    <!-- Searchable, sortable and paged table, inlined so the report works offline -->
+    <script>{{ .JS }}</script>
+  </body>
+</html>
+`
+
+// PortfolioMarkdown is the GitHub flavored Markdown portfolio report. It is a text template
+// so every value has to go through md.
+var PortfolioMarkdown = `
+{{- define "portfolio" -}}
+# Endeca Cartridge Portfolio
+
+{{ len .Portfolio.Cartridges }} cartridge IDs across {{ len .Portfolio.Apps }} applications: {{ .Portfolio.StatusCount "shared" }} shared, {{ .Portfolio.StatusCount "diverged" }} diverged and {{ .Portfolio.StatusCount "unique" }} unique.
+{{ range $index, $app := .Portfolio.Apps }}
+## {{ md $app.Name }}
+
+- **Report:** [{{ md (index $.Reports $index) }}]({{ index $.Reports $index }})
+- **Cartridges:** {{ len $app.App.Cartridges }}
+- **Unique cartridges:** {{ $.Portfolio.UniqueCount $index }}
+- **Sites:** {{ len $app.App.Sites }}
+- **Pages:** {{ len $app.App.Pages }}
+- **Rules:** {{ len $app.App.Rules }}
+{{ end }}
+## Cartridge Matrix
+
+Letters mark the definitions of a cartridge, applications with the same letter define it the same way.
+
+| Cartridge | Status |{{ range .Portfolio.Apps }} {{ md .Name }} |{{ end }}
+| --- | --- |{{ range .Portfolio.Apps }} --- |{{ end }}
+{{- range .Portfolio.Cartridges }}
+| {{ md .ID }} | {{ .Status }} |{{ range .Variants }} {{ variant . }} |{{ end }}
+{{- end }}
+{{ end }}
+`
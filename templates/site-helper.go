@@ -0,0 +1,267 @@
+package templates
+
+import (
+	"encoding/json"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// sitePageData is what every page of the static documentation site is executed with
+type sitePageData struct {
+	// Root is the relative path from the page back to the root of the site
+	Root       string
+	Title      string
+	Active     string
+	CSS        template.CSS
+	JS         template.JS
+	App        endeca.Application
+	Handlers   bool
+	Renderers  bool
+	Cartridge  endeca.Cartridge
+	Site       endeca.Site
+	Page       endeca.Page
+	Pages      []endeca.Page
+	Cartridges []string
+}
+
+// linkData is what the cartridgeLink template is executed with
+type linkData struct {
+	Root string
+	ID   string
+}
+
+// componentData is what the component template is executed with
+type componentData struct {
+	Root      string
+	Component endeca.Component
+}
+
+// SiteOutputHTML receives the mapped application and by using the SitePages templates it
+// produces a static documentation site: an index of cartridges, sites and pages plus a page
+// for every cartridge, site and Endeca page, all linked to each other.
+func SiteOutputHTML(app endeca.Application, handlers bool, renderers bool, outputPath string, DisableColor bool, Debug bool) error {
+	siteNames := endeca.SiteNames(app.Sites)
+	paths := newSitePaths(app)
+	cartridgeHref, siteHref, pageHref := paths.cartridgeHref, paths.siteHref, paths.pageHref
+	funcMap := template.FuncMap{
+		"siteName": func(siteID string) string {
+			if name, ok := siteNames[siteID]; ok {
+				return name
+			}
+			return siteID
+		},
+		"hasCartridge": func(cartridgeID string) bool {
+			_, ok := app.FindCartridge(cartridgeID)
+			return ok
+		},
+		"ruleFor": func(rulePath string) endeca.Rule {
+			rule, _ := app.FindRule(rulePath)
+			return rule
+		},
+		"cartridgeHref":  cartridgeHref,
+		"siteHref":       siteHref,
+		"pageHref":       pageHref,
+		"pagesUsing":     app.PagesUsing,
+		"pageCartridges": app.PageCartridges,
+		"siteCartridges": app.SiteCartridges,
+		"toJSON": func(value interface{}) string {
+			b, _ := json.Marshal(value)
+			return string(b)
+		},
+		"link": func(root string, id string) linkData {
+			return linkData{Root: root, ID: id}
+		},
+		"component": func(root string, component endeca.Component) componentData {
+			return componentData{Root: root, Component: component}
+		},
+	}
+	t, parseFileError := template.New("SitePages").Funcs(funcMap).Parse(SitePages)
+	if parseFileError != nil {
+		utils.DisplayError("Had a parsefile error", parseFileError, DisableColor)
+		return parseFileError
+	}
+
+	newPage := func(title string, active string) sitePageData {
+		return sitePageData{
+			Title:     title,
+			Active:    active,
+			CSS:       template.CSS(ReportCSS),
+			JS:        template.JS(ReportJS),
+			App:       app,
+			Handlers:  handlers,
+			Renderers: renderers,
+		}
+	}
+
+	pages := 0
+	var writeError error
+	write := func(name string, relPath string, data sitePageData) bool {
+		if writeError = writeSitePage(t, name, outputPath, relPath, data); writeError != nil {
+			utils.DisplayError("Couldn't write "+relPath, writeError, DisableColor)
+			return false
+		}
+		utils.DisplayDebug("Created "+relPath, Debug, DisableColor)
+		pages++
+		return true
+	}
+
+	if !write("index", "index.html", newPage("Cartridges", "cartridges")) ||
+		!write("sites", "sites.html", newPage("Sites", "sites")) ||
+		!write("pages", "pages.html", newPage("Pages", "pages")) {
+		return writeError
+	}
+
+	for _, cartridge := range app.Cartridges {
+		data := newPage(cartridge.GetID(), "cartridges")
+		data.Cartridge = cartridge
+		data.Pages = app.PagesUsing(cartridge.GetID())
+		if !write("cartridge", cartridgeHref(cartridge.GetID()), data) {
+			return writeError
+		}
+	}
+
+	for _, site := range app.Sites {
+		data := newPage(site.GetDisplayName(), "sites")
+		data.Site = site
+		data.Cartridges = app.SiteCartridges(site.GetID())
+		for _, page := range app.Pages {
+			if page.GetSite() == site.GetID() {
+				data.Pages = append(data.Pages, page)
+			}
+		}
+		if !write("site", siteHref(site.GetID()), data) {
+			return writeError
+		}
+	}
+
+	for _, page := range app.Pages {
+		data := newPage(page.GetName(), "pages")
+		data.Page = page
+		data.Cartridges = app.PageCartridges(page)
+		if !write("page", pageHref(page.GetKey()), data) {
+			return writeError
+		}
+	}
+
+	utils.DisplayInfo("Created documentation site with "+strconv.Itoa(pages)+" pages at "+outputPath+"/index.html", DisableColor)
+	return nil
+}
+
+// writeSitePage executes the named template into a file of the site
+func writeSitePage(t *template.Template, name string, outputPath string, relPath string, data sitePageData) error {
+	data.Root = strings.Repeat("../", strings.Count(relPath, "/"))
+	path := filepath.Join(outputPath, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	fo, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+	return t.ExecuteTemplate(fo, name, data)
+}
+
+// sitePaths are the paths of the cartridge, site and Endeca pages of the documentation site
+// relative to the site root, by cartridge ID, site ID and page key. Names that make the same
+// file name, also when only their case differs, get a -2, -3... suffix in the order of the
+// application so no page overwrites another on any file system.
+type sitePaths struct {
+	cartridges map[string]string
+	sites      map[string]string
+	pages      map[string]string
+}
+
+// newSitePaths assigns a unique path to every cartridge, site and page of the application
+func newSitePaths(app endeca.Application) sitePaths {
+	paths := sitePaths{
+		cartridges: make(map[string]string),
+		sites:      make(map[string]string),
+		pages:      make(map[string]string),
+	}
+	taken := make(map[string]bool)
+	assign := func(names map[string]string, name string, base string) {
+		if _, ok := names[name]; ok {
+			return
+		}
+		path := base + ".html"
+		for number := 2; taken[strings.ToLower(path)]; number++ {
+			path = base + "-" + strconv.Itoa(number) + ".html"
+		}
+		taken[strings.ToLower(path)] = true
+		names[name] = path
+	}
+	for _, cartridge := range app.Cartridges {
+		assign(paths.cartridges, cartridge.GetID(), cartridgeBase(cartridge.GetID()))
+	}
+	for _, site := range app.Sites {
+		assign(paths.sites, site.GetID(), siteBase(site.GetID()))
+	}
+	for _, page := range app.Pages {
+		assign(paths.pages, page.GetKey(), pageBase(page.GetKey()))
+	}
+	return paths
+}
+
+// cartridgeHref returns the path of a cartridge page relative to the site root
+func (p sitePaths) cartridgeHref(cartridgeID string) string {
+	if path, ok := p.cartridges[cartridgeID]; ok {
+		return path
+	}
+	return cartridgeBase(cartridgeID) + ".html"
+}
+
+// siteHref returns the path of a site page relative to the site root
+func (p sitePaths) siteHref(siteID string) string {
+	if path, ok := p.sites[siteID]; ok {
+		return path
+	}
+	return siteBase(siteID) + ".html"
+}
+
+// pageHref returns the path of an Endeca page relative to the site root
+func (p sitePaths) pageHref(pageKey string) string {
+	if path, ok := p.pages[pageKey]; ok {
+		return path
+	}
+	return pageBase(pageKey) + ".html"
+}
+
+// cartridgeBase returns the path of a cartridge page without extension
+func cartridgeBase(cartridgeID string) string {
+	return "cartridges/" + slug(cartridgeID)
+}
+
+// siteBase returns the path of a site page without extension
+func siteBase(siteID string) string {
+	return "sites/" + slug(siteID)
+}
+
+// pageBase returns the path of an Endeca page without extension. Pages are nested in
+// directories the same way they are nested under pages in the application.
+func pageBase(pageKey string) string {
+	var segments []string
+	for _, segment := range strings.Split(pageKey, "/") {
+		segments = append(segments, slug(segment))
+	}
+	return "pages/" + strings.Join(segments, "/")
+}
+
+// slug makes a name safe to use as a file name and URL path segment
+func slug(name string) string {
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
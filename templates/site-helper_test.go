@@ -0,0 +1,85 @@
+package templates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+)
+
+func TestSiteOutputHTML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the IDs all make the file name Hero_Banner, the last one only on case-insensitive file systems
+	basePath := filepath.Join(dir, "app")
+	for name, content := range map[string]string{
+		"templates/HeroA/template.xml": `<ContentTemplate type="MainContent" id="Hero Banner"><Description>First</Description></ContentTemplate>`,
+		"templates/HeroB/template.xml": `<ContentTemplate type="MainContent" id="Hero_Banner"><Description>Second</Description></ContentTemplate>`,
+		"templates/HeroC/template.xml": `<ContentTemplate type="MainContent" id="hero_banner"><Description>Third</Description></ContentTemplate>`,
+		"pages/Default/_.json":         `{"ecr:type":"site-home","displayName":"Default Store"}`,
+		"pages/Default/home/content.xml": `<ContentItem><TemplateId>OneColumn</TemplateId><Property name="main"><List>` +
+			`<ContentItem><TemplateId>Hero Banner</TemplateId></ContentItem>` +
+			`<ContentItem><TemplateId>Hero_Banner</TemplateId></ContentItem></List></Property></ContentItem>`,
+	} {
+		path := filepath.Join(basePath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := endeca.MapApplication(basePath, true, false)
+
+	outputPath := filepath.Join(dir, "site")
+	if err := SiteOutputHTML(app, false, false, outputPath, true, false); err != nil {
+		t.Fatal(err)
+	}
+	read := func(relPath string) string {
+		b, err := ioutil.ReadFile(filepath.Join(outputPath, filepath.FromSlash(relPath)))
+		if err != nil {
+			t.Errorf("the site has no %s: %v", relPath, err)
+		}
+		return string(b)
+	}
+
+	cartridgePages := map[string]string{
+		"cartridges/Hero_Banner.html":   "First",
+		"cartridges/Hero_Banner-2.html": "Second",
+		"cartridges/hero_banner-3.html": "Third",
+	}
+	for relPath, description := range cartridgePages {
+		if page := read(relPath); !strings.Contains(page, description) {
+			t.Errorf("%s isn't the page of the cartridge described %s", relPath, description)
+		}
+	}
+	index := read("index.html")
+	for relPath := range cartridgePages {
+		if !strings.Contains(index, `href="`+relPath+`"`) {
+			t.Errorf("index.html doesn't link to %s", relPath)
+		}
+	}
+
+	home := read("pages/Default/home.html")
+	for _, link := range []string{`href="../../cartridges/Hero_Banner.html"`, `href="../../cartridges/Hero_Banner-2.html"`, `href="../../sites/Default.html"`} {
+		if !strings.Contains(home, link) {
+			t.Errorf("pages/Default/home.html doesn't have the link %s", link)
+		}
+	}
+	if strings.Contains(home, "hero_banner-3.html") {
+		t.Error("pages/Default/home.html links to a cartridge it doesn't use")
+	}
+	if page := read("cartridges/Hero_Banner-2.html"); !strings.Contains(page, `href="../pages/Default/home.html"`) {
+		t.Error("the cartridge page doesn't link to the page using it")
+	}
+	if site := read("sites/Default.html"); !strings.Contains(site, `href="../pages/Default/home.html"`) {
+		t.Error("the site page doesn't link to its pages")
+	}
+}
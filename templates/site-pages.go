@@ -0,0 +1,372 @@
+package templates
+
+// SitePages are the templates of the static documentation site. Every page is executed
+// with a sitePageData, links are made relative to the site root through .Root so the
+// site can be opened from disk or served from any path.
+var SitePages = `
+{{ define "header" -}}
+<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1, shrink-to-fit=no">
+    <title>{{ .Title }} - Endeca Cartridge Mapper</title>
+    <style>{{ .CSS }}</style>
+  </head>
+  <body>
+    <nav class="navbar navbar-dark bg-dark mb-4">
+      <a class="navbar-brand" href="{{ .Root }}index.html">Endeca Cartridge Mapper</a>
+      <div class="navbar-collapse">
+        <ul class="navbar-nav mr-auto">
+          <li class="nav-item{{ if eq .Active "cartridges" }} active{{ end }}">
+            <a class="nav-link" href="{{ .Root }}index.html">Cartridges</a>
+          </li>
+          <li class="nav-item{{ if eq .Active "sites" }} active{{ end }}">
+            <a class="nav-link" href="{{ .Root }}sites.html">Sites</a>
+          </li>
+          <li class="nav-item{{ if eq .Active "pages" }} active{{ end }}">
+            <a class="nav-link" href="{{ .Root }}pages.html">Pages</a>
+          </li>
+        </ul>
+      </div>
+    </nav>
+    <div class="container">
+      <h1>{{ .Title }}</h1>
+{{- end }}
+
+{{ define "footer" }}
+    </div>
+    <script>{{ .JS }}</script>
+  </body>
+</html>
+{{- end }}
+
+{{ define "cartridgeLink" -}}
+  {{ if hasCartridge .ID -}}
+    <a href="{{ .Root }}{{ cartridgeHref .ID }}">{{ .ID }}</a>
+  {{- else -}}
+    {{ .ID }} <small class="text-muted">(no template)</small>
+  {{- end }}
+{{- end }}
+
+{{ define "index" -}}
+{{ template "header" . }}
+      <table class="table data-table">
+        <thead class="thead-inverse">
+          <tr>
+            <th>Cartridge</th>
+            <th>Description</th>
+            <th>Sites</th>
+            <th>Pages</th>
+            <th>Rules</th>
+          </tr>
+        </thead>
+        <tbody>
+          {{- range .App.Cartridges }}
+          <tr>
+            <td><a href="{{ $.Root }}{{ cartridgeHref .GetID }}">{{ .GetID }}</a></td>
+            <td>{{ .GetDescription }}</td>
+            <td>
+              {{- range .GetSites }}
+              <a href="{{ $.Root }}{{ siteHref . }}">{{ siteName . }}</a><br>
+              {{- else }}
+              <span class="text-muted">Cartridge not used in any site</span>
+              {{- end }}
+            </td>
+            <td>{{ len (pagesUsing .GetID) }}</td>
+            <td>{{ len .GetRules }}</td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- if .App.OrphanHandlers }}
+
+      <h2>Handlers without a template</h2>
+      <table class="table table-sm">
+        <thead>
+          <tr>
+            <th>Bean</th>
+            <th>Content item type</th>
+            <th>Class</th>
+          </tr>
+        </thead>
+        <tbody>
+          {{- range .App.OrphanHandlers }}
+          <tr>
+            <td><code>{{ .GetBeanID }}</code></td>
+            <td>{{ .GetContentType }}</td>
+            <td><code>{{ .GetClassName }}</code></td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- end }}
+      {{- if .App.OrphanRenderers }}
+
+      <h2>Renderers without a template</h2>
+      <table class="table table-sm">
+        <thead>
+          <tr>
+            <th>Content item type</th>
+            <th>Renderer</th>
+          </tr>
+        </thead>
+        <tbody>
+          {{- range .App.OrphanRenderers }}
+          <tr>
+            <td>{{ .GetContentType }}</td>
+            <td><code>{{ .GetPath }}</code></td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- end }}
+{{ template "footer" . }}
+{{- end }}
+
+{{ define "sites" -}}
+{{ template "header" . }}
+      <table class="table data-table">
+        <thead class="thead-inverse">
+          <tr>
+            <th>Site</th>
+            <th>ID</th>
+            <th>URL pattern</th>
+            <th>Pages</th>
+            <th>Cartridges</th>
+          </tr>
+        </thead>
+        <tbody>
+          {{- range .App.Sites }}
+          <tr>
+            <td><a href="{{ $.Root }}{{ siteHref .GetID }}">{{ .GetDisplayName }}</a></td>
+            <td><code>{{ .GetID }}</code></td>
+            <td>{{ .GetURLPattern }}</td>
+            <td>{{ len .GetPages }}</td>
+            <td>{{ len (siteCartridges .GetID) }}</td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+{{ template "footer" . }}
+{{- end }}
+
+{{ define "pages" -}}
+{{ template "header" . }}
+      <table class="table data-table">
+        <thead class="thead-inverse">
+          <tr>
+            <th>Page</th>
+            <th>Site</th>
+            <th>Cartridges</th>
+            <th>Rules</th>
+          </tr>
+        </thead>
+        <tbody>
+          {{- range .App.Pages }}
+          <tr>
+            <td><a href="{{ $.Root }}{{ pageHref .GetKey }}">{{ .GetName }}</a></td>
+            <td><a href="{{ $.Root }}{{ siteHref .GetSite }}">{{ siteName .GetSite }}</a></td>
+            <td>{{ len (pageCartridges .) }}</td>
+            <td>{{ len .GetRules }}</td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+{{ template "footer" . }}
+{{- end }}
+
+{{ define "cartridge" -}}
+{{ template "header" . }}
+      <p>{{ .Cartridge.GetDescription }}</p>
+      {{- if .Cartridge.GetDescriptions }}
+      <table class="table table-sm">
+        <thead>
+          <tr>
+            <th>Locale</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          {{- range $locale, $description := .Cartridge.GetDescriptions }}
+          <tr><td><code>{{ $locale }}</code></td><td>{{ $description }}</td></tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- end }}
+      <table class="table">
+        <tbody>
+          <tr><th>ID</th><td><code>{{ .Cartridge.GetID }}</code></td></tr>
+          <tr><th>Template type</th><td>{{ .Cartridge.GetTemplateType }}</td></tr>
+          <tr><th>Template directory</th><td><code>templates/{{ .Cartridge.GetPath }}</code></td></tr>
+          {{- if .Handlers }}
+          <tr>
+            <th>Handler</th>
+            <td>
+              {{- with .Cartridge.GetHandler }}
+              {{ .GetClassName }} <small class="text-muted">({{ .GetBeanID }})</small>
+              {{- range $name, $value := .GetConfig }}
+              <br><small>{{ $name }}: {{ $value }}</small>
+              {{- end }}
+              {{- else }}
+              <span class="text-muted">No cartridge handler</span>
+              {{- end }}
+            </td>
+          </tr>
+          {{- end }}
+          {{- if .Renderers }}
+          <tr>
+            <th>Renderers</th>
+            <td>
+              {{- range .Cartridge.GetRenderers }}
+              <code>{{ . }}</code><br>
+              {{- else }}
+              <span class="text-muted">No renderer found</span>
+              {{- end }}
+            </td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+
+      <h2>Properties</h2>
+      {{- if .Cartridge.GetProperties }}
+      <table class="table">
+        <thead class="thead-inverse"><tr><th>Property</th><th>Type</th></tr></thead>
+        <tbody>
+          {{- range .Cartridge.GetProperties }}
+          <tr><td><code>{{ .GetName }}</code></td><td>{{ .GetType }}</td></tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- else }}
+      <p class="text-muted">The template defines no properties.</p>
+      {{- end }}
+
+      <h2>Rules</h2>
+      {{- if .Cartridge.GetRules }}
+      <table class="table">
+        <thead class="thead-inverse"><tr><th>Rule</th><th>Used on pages</th></tr></thead>
+        <tbody>
+          {{- range .Cartridge.GetRules }}
+          <tr>
+            <td><code>{{ . }}</code></td>
+            <td>
+              {{- range (ruleFor .).GetPages }}
+              <a href="{{ $.Root }}{{ pageHref . }}">{{ . }}</a><br>
+              {{- else }}
+              <span class="text-muted">Rule not used on any page</span>
+              {{- end }}
+            </td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- else }}
+      <p class="text-muted">No Rule found</p>
+      {{- end }}
+
+      <h2>Pages</h2>
+      {{- if .Pages }}
+      <table class="table data-table">
+        <thead class="thead-inverse"><tr><th>Page</th><th>Site</th></tr></thead>
+        <tbody>
+          {{- range .Pages }}
+          <tr>
+            <td><a href="{{ $.Root }}{{ pageHref .GetKey }}">{{ .GetName }}</a></td>
+            <td><a href="{{ $.Root }}{{ siteHref .GetSite }}">{{ siteName .GetSite }}</a></td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- else }}
+      <p class="text-muted">Cartridge not used on any page</p>
+      {{- end }}
+{{ template "footer" . }}
+{{- end }}
+
+{{ define "site" -}}
+{{ template "header" . }}
+      <table class="table">
+        <tbody>
+          <tr><th>ID</th><td><code>{{ .Site.GetID }}</code></td></tr>
+          <tr><th>URL pattern</th><td>{{ .Site.GetURLPattern }}</td></tr>
+          <tr><th>Default page</th><td>{{ .Site.GetDefaultPage }}</td></tr>
+          {{- with .Site.GetFilterState }}
+          <tr><th>Filter state</th><td><code>{{ toJSON . }}</code></td></tr>
+          {{- end }}
+        </tbody>
+      </table>
+
+      <h2>Pages</h2>
+      <table class="table data-table">
+        <thead class="thead-inverse"><tr><th>Page</th><th>Cartridges</th></tr></thead>
+        <tbody>
+          {{- range .Pages }}
+          <tr>
+            <td><a href="{{ $.Root }}{{ pageHref .GetKey }}">{{ .GetName }}</a></td>
+            <td>
+              {{- range pageCartridges . }}
+              {{ template "cartridgeLink" (link $.Root .) }}<br>
+              {{- end }}
+            </td>
+          </tr>
+          {{- end }}
+        </tbody>
+      </table>
+
+      <h2>Cartridges</h2>
+      {{- range .Cartridges }}
+      {{ template "cartridgeLink" (link $.Root .) }}<br>
+      {{- else }}
+      <p class="text-muted">No cartridges used in this site</p>
+      {{- end }}
+{{ template "footer" . }}
+{{- end }}
+
+{{ define "page" -}}
+{{ template "header" . }}
+      <table class="table">
+        <tbody>
+          <tr><th>Site</th><td><a href="{{ .Root }}{{ siteHref .Page.GetSite }}">{{ siteName .Page.GetSite }}</a></td></tr>
+          <tr><th>Content file</th><td><code>{{ .Page.GetPath }}</code></td></tr>
+          <tr>
+            <th>Cartridges</th>
+            <td>
+              {{- range .Cartridges }}
+              {{ template "cartridgeLink" (link $.Root .) }}<br>
+              {{- end }}
+            </td>
+          </tr>
+        </tbody>
+      </table>
+
+      <h2>Composition</h2>
+      <ul>
+        {{ template "component" (component .Root .Page.GetComposition) }}
+      </ul>
+{{ template "footer" . }}
+{{- end }}
+
+{{ define "component" -}}
+<li>
+  {{- with .Component.GetSlot }}<span class="text-muted">{{ . }}:</span> {{ end }}
+  {{- if .Component.GetRule }}
+  content rule <code>{{ .Component.GetRule }}</code>
+    {{- range (ruleFor .Component.GetRule).GetCartridges }}
+    {{ template "cartridgeLink" (link $.Root .) }}
+    {{- end }}
+  {{- else }}
+  {{ template "cartridgeLink" (link .Root .Component.GetTemplateID) }}
+  {{- with .Component.GetName }} <small class="text-muted">{{ . }}</small>{{ end }}
+  {{- end }}
+  {{- if .Component.GetChildren }}
+  <ul>
+    {{- range .Component.GetChildren }}
+    {{ template "component" (component $.Root .) }}
+    {{- end }}
+  </ul>
+  {{- end }}
+</li>
+{{- end }}
+`
@@ -0,0 +1,189 @@
+package templates
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnroach/cartridgemapper/endeca"
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+// spreadsheetFiles are the CSV files written for each sheet, in the order of cartridgeSheets
+var spreadsheetFiles = []string{"cartridges.csv", "cartridge-pages.csv", "cartridge-sites.csv", "cartridge-rules.csv", "cartridge-descriptions.csv",
+	"orphan-handlers.csv", "orphan-renderers.csv"}
+
+// cartridgeSheets lays the application out as one sheet of cartridges and a sheet for each
+// of the relations between cartridges and pages, sites, rules and localized descriptions,
+// one row per relation, followed by sheets of the handlers and renderers without a template
+func cartridgeSheets(app endeca.Application) []utils.Sheet {
+	siteNames := endeca.SiteNames(app.Sites)
+	cartridges := utils.Sheet{
+		Name: "Cartridges",
+		Rows: [][]interface{}{{"Cartridge", "Description", "Template Type", "Template Directory", "Handler", "Sites", "Pages", "Rules"}},
+	}
+	pages := utils.Sheet{
+		Name: "Cartridge Pages",
+		Rows: [][]interface{}{{"Cartridge", "Site ID", "Site", "Page", "Via Rule"}},
+	}
+	sites := utils.Sheet{
+		Name: "Cartridge Sites",
+		Rows: [][]interface{}{{"Cartridge", "Site ID", "Site"}},
+	}
+	rules := utils.Sheet{
+		Name: "Cartridge Rules",
+		Rows: [][]interface{}{{"Cartridge", "Rule"}},
+	}
+	descriptions := utils.Sheet{
+		Name: "Cartridge Descriptions",
+		Rows: [][]interface{}{{"Cartridge", "Locale", "Description"}},
+	}
+	orphanHandlers := utils.Sheet{
+		Name: "Orphan Handlers",
+		Rows: [][]interface{}{{"Bean", "Content Item Type", "Handler"}},
+	}
+	orphanRenderers := utils.Sheet{
+		Name: "Orphan Renderers",
+		Rows: [][]interface{}{{"Content Item Type", "Renderer"}},
+	}
+
+	for _, cartridge := range app.Cartridges {
+		var handler string
+		if cartridge.GetHandler() != nil {
+			handler = cartridge.GetHandler().GetClassName()
+		}
+		cartridgePages := app.PagesUsing(cartridge.GetID())
+		cartridges.Rows = append(cartridges.Rows, []interface{}{
+			cartridge.GetID(),
+			cartridge.GetDescription(),
+			cartridge.GetTemplateType(),
+			cartridge.GetPath(),
+			handler,
+			len(cartridge.GetSites()),
+			len(cartridgePages),
+			len(cartridge.GetRules()),
+		})
+
+		for _, page := range cartridgePages {
+			for _, via := range pageUsageVia(app, page, cartridge.GetID()) {
+				pages.Rows = append(pages.Rows, []interface{}{
+					cartridge.GetID(), page.GetSite(), siteNames[page.GetSite()], page.GetName(), via,
+				})
+			}
+		}
+		for _, site := range cartridge.GetSites() {
+			sites.Rows = append(sites.Rows, []interface{}{cartridge.GetID(), site, siteNames[site]})
+		}
+		for _, rule := range cartridge.GetRules() {
+			rules.Rows = append(rules.Rows, []interface{}{cartridge.GetID(), rule})
+		}
+		for _, locale := range sortedLocales(cartridge.GetDescriptions()) {
+			descriptions.Rows = append(descriptions.Rows, []interface{}{cartridge.GetID(), locale, cartridge.GetDescriptions()[locale]})
+		}
+	}
+	for _, handler := range app.OrphanHandlers {
+		orphanHandlers.Rows = append(orphanHandlers.Rows, []interface{}{handler.GetBeanID(), handler.GetContentType(), handler.GetClassName()})
+	}
+	for _, renderer := range app.OrphanRenderers {
+		orphanRenderers.Rows = append(orphanRenderers.Rows, []interface{}{renderer.GetContentType(), renderer.GetPath()})
+	}
+	return []utils.Sheet{cartridges, pages, sites, rules, descriptions, orphanHandlers, orphanRenderers}
+}
+
+// sortedLocales returns the locales of localized descriptions in order
+func sortedLocales(descriptions map[string]string) []string {
+	var locales []string
+	for locale := range descriptions {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// pageUsageVia returns how a page uses a cartridge: an empty string when it is placed on the
+// page directly and the rule path for every content rule it is pulled in through
+func pageUsageVia(app endeca.Application, page endeca.Page, cartridgeID string) []string {
+	var via []string
+	for _, pageCartridgeID := range page.GetCartridges() {
+		if pageCartridgeID == cartridgeID {
+			via = append(via, "")
+			break
+		}
+	}
+	for _, rulePath := range page.GetRules() {
+		rule, _ := app.FindRule(rulePath)
+		for _, ruleCartridgeID := range rule.GetCartridges() {
+			if ruleCartridgeID == cartridgeID {
+				via = append(via, rulePath)
+				break
+			}
+		}
+	}
+	return via
+}
+
+// CartridgeOutputCSV writes the cartridge sheets as one CSV file each
+func CartridgeOutputCSV(app endeca.Application, outputPath string, DisableColor bool, Debug bool) error {
+	for index, sheet := range cartridgeSheets(app) {
+		path := filepath.Join(outputPath, spreadsheetFiles[index])
+		if err := writeCSV(path, sheet); err != nil {
+			utils.DisplayError("Couldn't write "+path, err, DisableColor)
+			return err
+		}
+		utils.DisplayInfo("Created "+spreadsheetFiles[index]+" file at "+path, DisableColor)
+	}
+	return nil
+}
+
+// CartridgeOutputXLSX writes the cartridge sheets as one Excel workbook
+func CartridgeOutputXLSX(app endeca.Application, outputPath string, DisableColor bool, Debug bool) error {
+	path := filepath.Join(outputPath, "cartridges.xlsx")
+	if err := utils.WriteXLSX(path, cartridgeSheets(app)); err != nil {
+		utils.DisplayError("Couldn't write "+path, err, DisableColor)
+		return err
+	}
+	utils.DisplayInfo("Created cartridges.xlsx file at "+path, DisableColor)
+	return nil
+}
+
+// csvFormulaPrefixes start the cells spreadsheet applications evaluate as formulas
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvCell returns the text of a CSV cell. Text from the export starting like a formula is
+// prefixed with ' so opening the file doesn't evaluate it, numbers are written as they are.
+// XLSX cells are written as inline strings, which are never evaluated.
+func csvCell(value interface{}) string {
+	text, ok := value.(string)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	if text != "" && strings.ContainsRune(csvFormulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// writeCSV writes the rows of a sheet as CSV
+func writeCSV(path string, sheet utils.Sheet) error {
+	fo, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+
+	w := csv.NewWriter(fo)
+	for _, row := range sheet.Rows {
+		record := make([]string, len(row))
+		for index, value := range row {
+			record[index] = csvCell(value)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,69 @@
+package templates
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnroach/cartridgemapper/utils"
+)
+
+func TestSpreadsheetCellsAreNotFormulas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spreadsheet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sheet := utils.Sheet{Name: "Cartridges", Rows: [][]interface{}{
+		{"Cartridge", "Description", "Pages"},
+		{"=HYPERLINK(\"http://x\")", "+1 banner", 3},
+		{"@SUM(A1)", "-10% off", 0},
+		{"Hero", "a = b", 12},
+	}}
+
+	csvPath := filepath.Join(dir, "cartridges.csv")
+	if err := writeCSV(csvPath, sheet); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Cartridge,Description,Pages\n" +
+		"\"'=HYPERLINK(\"\"http://x\"\")\",'+1 banner,3\n" +
+		"'@SUM(A1),'-10% off,0\n" +
+		"Hero,a = b,12\n"
+	if string(b) != expected {
+		t.Errorf("writeCSV() wrote\n%s\nexpected\n%s", b, expected)
+	}
+
+	xlsxPath := filepath.Join(dir, "cartridges.xlsx")
+	if err := utils.WriteXLSX(xlsxPath, []utils.Sheet{sheet}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.OpenReader(xlsxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		worksheet, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(worksheet), "<f>") || !strings.Contains(string(worksheet), `t="inlineStr"><is><t xml:space="preserve">=HYPERLINK`) {
+			t.Errorf("the worksheet doesn't write the cells as inline strings: %s", worksheet)
+		}
+	}
+}
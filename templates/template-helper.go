@@ -8,26 +8,62 @@ import (
 	"github.com/johnroach/cartridgemapper/utils"
 )
 
-//CartridgeOutputHTML receives the cartridges and by using the IndexPage template
-//in templates it produces a cool looking HTML page that can be used.
-func CartridgeOutputHTML(cartridges []endeca.Cartridge, outputPath string, DisableColor bool, Debug bool) {
-	t, parseFileError := template.New("IndexPage").Parse(IndexPage)
+// indexPageData is what the IndexPage template is executed with
+type indexPageData struct {
+	Cartridges []endeca.Cartridge
+	Sites      []endeca.Site
+	// OrphanHandlers are the handler beans without a template
+	OrphanHandlers []endeca.Handler
+	// OrphanRenderers are the renderers for content item types without a template
+	OrphanRenderers []endeca.Renderer
+	Handlers        bool
+	Renderers       bool
+	CSS             template.CSS
+	JS              template.JS
+}
+
+//CartridgeOutputHTML receives the mapped application and by using the IndexPage template
+//in templates it produces a cool looking HTML page that can be used. The handler and renderer
+//columns are only shown when the cartridges were linked to an assembler config or storefront,
+//handlers and renderers without a template are listed below the cartridges.
+func CartridgeOutputHTML(app endeca.Application, handlers bool, renderers bool, outputPath string, DisableColor bool, Debug bool) error {
+	siteNames := endeca.SiteNames(app.Sites)
+	funcMap := template.FuncMap{
+		// siteName shows the display name of a site, falling back to its ID
+		"siteName": func(siteID string) string {
+			if name, ok := siteNames[siteID]; ok {
+				return name
+			}
+			return siteID
+		},
+	}
+	t, parseFileError := template.New("IndexPage").Funcs(funcMap).Parse(IndexPage)
 	if parseFileError != nil {
 		utils.DisplayError("Had a parsefile error", parseFileError, DisableColor)
-		return
+		return parseFileError
 	}
 
 	fo, createOutputError := os.Create(outputPath + "/index.html")
 	if createOutputError != nil {
 		utils.DisplayError("Failed to create output", createOutputError, DisableColor)
-		return
+		return createOutputError
 	}
 
-	templateExecuteError := t.ExecuteTemplate(fo, "IndexPage", cartridges)
+	templateExecuteError := t.ExecuteTemplate(fo, "IndexPage", indexPageData{
+		Cartridges:      app.Cartridges,
+		Sites:           app.Sites,
+		OrphanHandlers:  app.OrphanHandlers,
+		OrphanRenderers: app.OrphanRenderers,
+		Handlers:        handlers,
+		Renderers:       renderers,
+		CSS:             template.CSS(ReportCSS),
+		JS:              template.JS(ReportJS),
+	})
 	fo.Close()
 	if templateExecuteError != nil {
 		utils.DisplayError("Had a template execute error", templateExecuteError, DisableColor)
-		return
+		return templateExecuteError
 	}
 	utils.DisplayInfo("Created index.html file at "+outputPath+"/index.html", DisableColor)
+	return nil
 }
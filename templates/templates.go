@@ -1,7 +1,8 @@
 package templates
 
 //I haven't been able to find a better way to package templates within the binary
-//if you find a better way please feel free to implement it.
+//if you find a better way please feel free to implement it. The templates don't
+//load anything from the network, styles and scripts come from assets.go.
 
 // IndexPage template
 var IndexPage = `
@@ -12,16 +13,10 @@ var IndexPage = `
     <meta charset="utf-8">
     <meta name="viewport" content="width=device-width, initial-scale=1, shrink-to-fit=no">
 
-    <!-- Bootstrap CSS -->
-    <link rel="stylesheet" href="https://maxcdn.bootstrapcdn.com/bootstrap/4.0.0-beta/css/bootstrap.min.css" integrity="sha384-/Y6pD6FV/Vv2HJnA6t+vslU6fwYXjCFtcEpHbNJ0lyAFsXTsjBbfaDjzALeQsN6M" crossorigin="anonymous">
-    <style>
-    /* Show it's not fixed to the top */
-    body {
-      min-height: 75rem;
-    }
-    </style>
+    <title>Endeca Cartridge Mapper</title>
 
-  <link rel="stylesheet" type="text/css" href="https://cdn.datatables.net/1.10.16/css/jquery.dataTables.min.css">
+    <!-- Report styles, inlined so the report works offline -->
+    <style>{{ .CSS }}</style>
   </head>
   <body>
 
@@ -44,7 +39,7 @@ var IndexPage = `
         </nav>
 
         <div class="container">
-          <table class="table" id="table_id">
+          <table class="table data-table" id="table_id">
             <thead class="thead-inverse">
               <tr>
                 <th>Cartridge Name</th>
@@ -52,13 +47,24 @@ var IndexPage = `
                 <th>Rules</th>
                 <th>Sites</th>
                 <th>Pages</th>
+                {{- if .Handlers }}
+                <th>Handler</th>
+                {{- end }}
+                {{- if .Renderers }}
+                <th>Renderers</th>
+                {{- end }}
               </tr>
             </thead>
             <tbody>
-              {{ range . }}
+              {{ range .Cartridges }}
               <tr>
               <td>{{ .GetID }}</td>
-              <td>{{ .GetDescription }}</td>
+              <td>
+                {{ .GetDescription }}
+                {{- range $locale, $description := .GetDescriptions }}
+                <br><small>{{ $locale }}: {{ $description }}</small>
+                {{- end }}
+              </td>
               <td>
                 {{if .GetRules -}}
                   {{- range .GetRules }}
@@ -71,7 +77,7 @@ var IndexPage = `
               <td>
                 {{ if .GetSites -}}
                   {{- range .GetSites}}
-                    {{ . }}<br>
+                    {{ siteName . }}<br>
                   {{- end}}
                 {{- else}}
                   Cartridge not used in any site
@@ -86,24 +92,80 @@ var IndexPage = `
                 Page is not used in any site
               {{- end }}
               </td>
+              {{- if $.Handlers }}
+              <td>
+              {{ with .GetHandler -}}
+                {{ .GetClassName }}<br>
+                <small>{{ .GetBeanID }}</small>
+                {{- range $name, $value := .GetConfig }}
+                <br><small>{{ $name }}: {{ $value }}</small>
+                {{- end }}
+              {{- else -}}
+                No cartridge handler
+              {{- end }}
+              </td>
+              {{- end }}
+              {{- if $.Renderers }}
+              <td>
+              {{ if .GetRenderers -}}
+                {{- range .GetRenderers }}
+                  {{ . }}<br>
+                {{- end }}
+              {{- else -}}
+                No renderer found
+              {{- end }}
+              </td>
+              {{- end }}
               </tr>
               {{ end }}
             </tbody>
           </table>
+          {{- if .OrphanHandlers }}
+
+          <h2>Handlers without a template</h2>
+          <table class="table table-sm">
+            <thead>
+              <tr>
+                <th>Bean</th>
+                <th>Content item type</th>
+                <th>Class</th>
+              </tr>
+            </thead>
+            <tbody>
+              {{- range .OrphanHandlers }}
+              <tr>
+                <td>{{ .GetBeanID }}</td>
+                <td>{{ .GetContentType }}</td>
+                <td>{{ .GetClassName }}</td>
+              </tr>
+              {{- end }}
+            </tbody>
+          </table>
+          {{- end }}
+          {{- if .OrphanRenderers }}
+
+          <h2>Renderers without a template</h2>
+          <table class="table table-sm">
+            <thead>
+              <tr>
+                <th>Content item type</th>
+                <th>Renderer</th>
+              </tr>
+            </thead>
+            <tbody>
+              {{- range .OrphanRenderers }}
+              <tr>
+                <td>{{ .GetContentType }}</td>
+                <td>{{ .GetPath }}</td>
+              </tr>
+              {{- end }}
+            </tbody>
+          </table>
+          {{- end }}
         </div>
 
-    <!-- Optional JavaScript -->
-    <!-- jQuery first, then Popper.js, then Bootstrap JS -->
-    <script src="https://code.jquery.com/jquery-3.2.1.min.js"></script>
-    <script src="https://cdnjs.cloudflare.com/ajax/libs/popper.js/1.11.0/umd/popper.min.js" integrity="sha384-b/U6ypiBEHpOf/4+1nzFpr53nxSS+GLCkfwBdFNTxtclqqenISfwAzpKaMNFNmj4" crossorigin="anonymous"></script>
-    <script type="text/javascript" language="javascript" src="https://cdn.datatables.net/1.10.16/js/jquery.dataTables.min.js">
-</script>
-    <script src="https://maxcdn.bootstrapcdn.com/bootstrap/4.0.0-beta/js/bootstrap.min.js" integrity="sha384-h0AbiXch4ZDo7tp9hKZ4TsHbi047NrKGLO3SEJAg45jXxnGIfYzk4Si90RDIqNm1" crossorigin="anonymous"></script>
-      <script>
-    $(document).ready( function () {
-      $('#table_id').DataTable();
-    } );
-    </script>
+    <!-- Searchable, sortable and paged table, inlined so the report works offline -->
+    <script>{{ .JS }}</script>
   </body>
 </html>
 `
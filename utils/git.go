@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Commit is a commit of a local git repository
+type Commit struct {
+	Hash    string    `json:"commit"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+}
+
+// ShortHash returns the abbreviated hash of the commit
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// gitCommand runs git in a repository and returns its output. Only the local repository
+// is read, git is never asked to fetch.
+func gitCommand(repo string, args ...string) ([]byte, error) {
+	command := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	out, err := command.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return out, errors.New("git " + args[0] + ": " + message)
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+// checkRevision refuses revisions git would read as an option, like --output=file, since
+// revisions can come from a config file as well as the command line
+func checkRevision(revision string) error {
+	if revision == "" || strings.HasPrefix(revision, "-") {
+		return errors.New("invalid git revision \"" + revision + "\"")
+	}
+	return nil
+}
+
+// GitCommits returns the commits reachable from a revision that change a path of the
+// repository, oldest first. An empty path returns every commit.
+func GitCommits(repo string, revision string, path string) ([]Commit, error) {
+	if err := checkRevision(revision); err != nil {
+		return nil, err
+	}
+	args := []string{"log", "--reverse", "--format=%H%x00%an%x00%aI%x00%s", revision}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	out, err := gitCommand(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		commitTime, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, Commit{Hash: fields[0], Author: fields[1], Time: commitTime, Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+// GitArchive writes a path of the repository at a commit as a zip archive, the whole tree
+// for an empty path. The files keep their path in the repository.
+func GitArchive(repo string, commit string, path string, dest string) error {
+	if err := checkRevision(commit); err != nil {
+		return err
+	}
+	args := []string{"archive", "--format=zip", "--output", dest, commit}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	_, err := gitCommand(repo, args...)
+	return err
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitRejectsOptionRevisions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "written")
+
+	if _, err := GitCommits(dir, "--output="+output, ""); err == nil {
+		t.Error("GitCommits() accepted a revision starting with -")
+	}
+	if err := GitArchive(dir, "--remote=elsewhere", "", filepath.Join(dir, "out.zip")); err == nil {
+		t.Error("GitArchive() accepted a commit starting with -")
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("git wrote the file of the revision option: %v", err)
+	}
+}
@@ -1,93 +1,330 @@
 package utils
 
 import (
-	"log"
+	"encoding/json"
+	"errors"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/fatih/color"
 )
 
-var (
-	// Info logger
-	Info *log.Logger
-	// Warning logger
-	Warning *log.Logger
-	// Error logger
-	Error *log.Logger
-	// Debug logger
-	Debug *log.Logger
+// Level is the severity of a log entry, more verbose levels are higher
+type Level int
+
+// Log levels, from least to most verbose
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+	LevelTrace
 )
 
-// LogInfo displays info type output
-func logInfo(message string, colorDisabled bool) {
-	Info = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime)
-	if !colorDisabled {
-		color.Set(color.FgGreen)
+var levelNames = []string{"error", "warn", "info", "debug", "trace"}
+var levelPrefixes = []string{"ERROR: ", "WARNING: ", "INFO: ", "DEBUG: ", "TRACE: "}
+var levelColors = []color.Attribute{color.FgRed, color.FgYellow, color.FgGreen, color.FgHiBlue, color.FgHiBlack}
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogOptions configures the logging of a run
+type LogOptions struct {
+	Level Level
+	// Format is LogFormatText or LogFormatJSON
+	Format string
+	// File is a file every entry is appended to next to the terminal output, without colors
+	File string
+	// DisableColor turns colors off, they are only used on a terminal anyway
+	DisableColor bool
+}
+
+// Fields are context fields added to a log entry, e.g. the file being parsed
+type Fields map[string]interface{}
+
+// Entry is a log entry with context fields, created with WithFields
+type Entry struct {
+	fields Fields
+}
+
+// logger writes the log entries. Errors go to stderr, everything else to stdout.
+type logger struct {
+	mu           sync.Mutex
+	level        Level
+	format       string
+	color        bool
+	stdout       io.Writer
+	stderr       io.Writer
+	file         *os.File
+	now          func() time.Time
+	warningCount int
+	errorCount   int
+}
+
+var defaultLogger = newLogger(os.Stdout, os.Stderr)
+
+// newLogger creates a text logger at info level, using colors when stdout is a terminal
+func newLogger(stdout io.Writer, stderr io.Writer) *logger {
+	return &logger{
+		level:  LevelInfo,
+		format: LogFormatText,
+		color:  colorSupported(),
+		stdout: stdout,
+		stderr: stderr,
+		now:    time.Now,
 	}
-	Info.Println(message)
-	if !colorDisabled {
-		color.Unset()
+}
+
+// colorSupported checks if stdout is a terminal and NO_COLOR isn't set
+func colorSupported() bool {
+	return !color.NoColor && os.Getenv("NO_COLOR") == ""
+}
+
+// String returns the name of the level
+func (level Level) String() string {
+	if level < LevelError || level > LevelTrace {
+		return "level(" + strconv.Itoa(int(level)) + ")"
 	}
+	return levelNames[level]
 }
 
-// LogWarning displays warning type output
-func logWarning(message string, colorDisabled bool) {
-	Info = log.New(os.Stdout, "WARNING: ", log.Ldate|log.Ltime)
-	if !colorDisabled {
-		color.Set(color.FgYellow)
+// ParseLevel returns the level with the given name
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(name)
+	if name == "warning" {
+		return LevelWarn, nil
 	}
-	Info.Println(message)
-	if !colorDisabled {
-		color.Unset()
+	for index, levelName := range levelNames {
+		if name == levelName {
+			return Level(index), nil
+		}
 	}
+	return LevelInfo, errors.New("unknown log level " + name + ", use one of " + strings.Join(levelNames, ", "))
 }
 
-// LogDebug displays debug type output
-func logDebug(message string, colorDisabled bool) {
-	Debug = log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime)
-	if !colorDisabled {
-		color.Set(color.FgHiBlue)
+// ConfigureLogging sets the level, format, log file and colors of all logging
+func ConfigureLogging(options LogOptions) error {
+	if options.Format != LogFormatText && options.Format != LogFormatJSON {
+		return errors.New("unknown log format " + options.Format + ", use " + LogFormatText + " or " + LogFormatJSON)
+	}
+	var file *os.File
+	if options.File != "" {
+		var err error
+		file, err = os.OpenFile(options.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+	if defaultLogger.file != nil {
+		defaultLogger.file.Close()
 	}
-	Debug.Println(message)
-	if !colorDisabled {
-		color.Unset()
+	defaultLogger.level = options.Level
+	defaultLogger.format = options.Format
+	defaultLogger.file = file
+	defaultLogger.color = !options.DisableColor && colorSupported()
+	return nil
+}
+
+// CloseLogging closes the log file, if any
+func CloseLogging() error {
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+	if defaultLogger.file == nil {
+		return nil
 	}
+	err := defaultLogger.file.Close()
+	defaultLogger.file = nil
+	return err
 }
 
-// LogError displays error type output
-func logError(message string, colorDisabled bool) {
-	Error = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime)
-	if !colorDisabled {
-		color.Set(color.FgRed)
+// LogLevel returns the configured log level
+func LogLevel() Level {
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+	return defaultLogger.level
+}
+
+// log writes an entry when its level is enabled. Warnings and errors are always counted.
+func (l *logger) log(level Level, message string, err error, fields Fields, force bool, disableColor bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch level {
+	case LevelError:
+		l.errorCount++
+	case LevelWarn:
+		l.warningCount++
+	}
+	if level > l.level && !force {
+		return
 	}
-	Error.Println(message)
-	if !colorDisabled {
-		color.Unset()
+
+	now := l.now()
+	var line string
+	if l.format == LogFormatJSON {
+		line = jsonLine(now, level, message, err, fields)
+	} else {
+		line = textLine(now, level, message, err, fields)
+	}
+
+	out := l.stdout
+	if level == LevelError {
+		out = l.stderr
+	}
+	if l == defaultLogger {
+		clearProgress()
+		defer redrawProgress()
+	}
+	if l.color && !disableColor && l.format == LogFormatText {
+		c := color.New(levelColors[level])
+		c.EnableColor()
+		io.WriteString(out, c.Sprint(line)+"\n")
+	} else {
+		io.WriteString(out, line+"\n")
+	}
+	if l.file != nil {
+		io.WriteString(l.file, line+"\n")
 	}
 }
 
-//DisplayError gets the error and the message to show to the user and displays it
-func DisplayError(message string, err error, DisableColor bool) {
-	var errorMessage string
+// textLine formats an entry as "LEVEL: date time message error key=value"
+func textLine(now time.Time, level Level, message string, err error, fields Fields) string {
+	line := levelPrefixes[level] + now.Format("2006/01/02 15:04:05") + " " + message
 	if err != nil {
-		errorMessage = err.Error()
+		line += " " + err.Error()
+	}
+	for _, key := range sortedKeys(fields) {
+		value := toString(fields[key])
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+		line += " " + key + "=" + value
 	}
-	logError(message+" "+errorMessage, DisableColor)
+	return line
 }
 
-//DisplayDebug displays debug messages
-func DisplayDebug(message string, Debug bool, DisableColor bool) {
-	if Debug {
-		logDebug(message, DisableColor)
+// jsonLine formats an entry as a JSON object with the time, level, msg, error and fields
+func jsonLine(now time.Time, level Level, message string, err error, fields Fields) string {
+	entry := make(map[string]interface{}, len(fields)+4)
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["time"] = now.Format(time.RFC3339)
+	entry["level"] = level.String()
+	entry["msg"] = message
+	if err != nil {
+		entry["error"] = err.Error()
+	}
+	b, marshalError := json.Marshal(entry)
+	if marshalError != nil {
+		return `{"level":"error","msg":` + strconv.Quote("Couldn't log entry: "+marshalError.Error()) + `}`
+	}
+	return string(b)
+}
+
+// sortedKeys returns the keys of the fields in order so text lines are stable
+func sortedKeys(fields Fields) []string {
+	var keys []string
+	for key := range fields {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toString formats a field value for a text line
+func toString(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case error:
+		return typed.Error()
+	default:
+		b, _ := json.Marshal(typed)
+		return string(b)
+	}
+}
+
+// WithFields creates an entry with context fields, e.g. WithFields(Fields{"file": path})
+func WithFields(fields Fields) Entry {
+	return Entry{fields: fields}
+}
+
+// WithFields adds more context fields to an entry
+func (e Entry) WithFields(fields Fields) Entry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	for key, value := range e.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return Entry{fields: merged}
+}
+
+// Error logs an error with the context fields
+func (e Entry) Error(message string, err error) {
+	defaultLogger.log(LevelError, message, err, e.fields, false, false)
+}
+
+// Warn logs a warning with the context fields
+func (e Entry) Warn(message string) {
+	defaultLogger.log(LevelWarn, message, nil, e.fields, false, false)
+}
+
+// Info logs an info message with the context fields
+func (e Entry) Info(message string) {
+	defaultLogger.log(LevelInfo, message, nil, e.fields, false, false)
+}
+
+// Debug logs a debug message with the context fields
+func (e Entry) Debug(message string) {
+	defaultLogger.log(LevelDebug, message, nil, e.fields, false, false)
 }
 
-//DisplayWarning displays warning messages
+// Trace logs a trace message with the context fields
+func (e Entry) Trace(message string) {
+	defaultLogger.log(LevelTrace, message, nil, e.fields, false, false)
+}
+
+// DisplayError gets the error and the message to show to the user and displays it
+func DisplayError(message string, err error, DisableColor bool) {
+	defaultLogger.log(LevelError, message, err, nil, false, DisableColor)
+}
+
+// DisplayDebug displays debug messages when Debug is set or the log level is debug or trace
+func DisplayDebug(message string, Debug bool, DisableColor bool) {
+	defaultLogger.log(LevelDebug, message, nil, nil, Debug, DisableColor)
+}
+
+// DisplayTrace displays trace messages, the most verbose level
+func DisplayTrace(message string, DisableColor bool) {
+	defaultLogger.log(LevelTrace, message, nil, nil, false, DisableColor)
+}
+
+// DisplayWarning displays warning messages
 func DisplayWarning(message string, DisableColor bool) {
-	logWarning(message, DisableColor)
+	defaultLogger.log(LevelWarn, message, nil, nil, false, DisableColor)
 }
 
-//DisplayInfo displays info messages
+// DisplayInfo displays info messages
 func DisplayInfo(message string, DisableColor bool) {
-	logInfo(message, DisableColor)
+	defaultLogger.log(LevelInfo, message, nil, nil, false, DisableColor)
+}
+
+// LogCounts returns the number of warnings and errors logged so far
+func LogCounts() (warnings int, errors int) {
+	defaultLogger.mu.Lock()
+	defer defaultLogger.mu.Unlock()
+	return defaultLogger.warningCount, defaultLogger.errorCount
 }
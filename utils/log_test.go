@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLogger(format string, level Level) (*logger, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	l := newLogger(&stdout, &stderr)
+	l.format = format
+	l.level = level
+	l.color = false
+	l.now = func() time.Time {
+		return time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+	}
+	return l, &stdout, &stderr
+}
+
+func TestLoggerText(t *testing.T) {
+	l, stdout, stderr := testLogger(LogFormatText, LevelInfo)
+	l.log(LevelInfo, "Scanned", nil, Fields{"file": "pages/Default/home/content.xml", "count": 2}, false, false)
+	l.log(LevelDebug, "Hidden", nil, nil, false, false)
+	l.log(LevelError, "Couldn't parse", errors.New("unexpected EOF"), Fields{"file": "a b.xml"}, false, false)
+
+	if expected := "INFO: 2018/03/04 05:06:07 Scanned count=2 file=pages/Default/home/content.xml\n"; stdout.String() != expected {
+		t.Errorf("text log wrote %q to stdout, expected %q", stdout.String(), expected)
+	}
+	if expected := "ERROR: 2018/03/04 05:06:07 Couldn't parse unexpected EOF file=\"a b.xml\"\n"; stderr.String() != expected {
+		t.Errorf("text log wrote %q to stderr, expected %q", stderr.String(), expected)
+	}
+}
+
+func TestLoggerJSON(t *testing.T) {
+	l, stdout, _ := testLogger(LogFormatJSON, LevelTrace)
+	l.log(LevelTrace, "Scanning", nil, Fields{"file": "content.xml"}, false, false)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &entry); err != nil {
+		t.Fatalf("JSON log wrote invalid JSON %q: %v", stdout.String(), err)
+	}
+	if entry["level"] != "trace" || entry["msg"] != "Scanning" || entry["file"] != "content.xml" || entry["time"] != "2018-03-04T05:06:07Z" {
+		t.Errorf("JSON log wrote wrong entry %v", entry)
+	}
+}
+
+func TestLoggerCounts(t *testing.T) {
+	l, stdout, _ := testLogger(LogFormatText, LevelError)
+	l.log(LevelWarn, "Counted but hidden", nil, nil, false, false)
+	l.log(LevelDebug, "Forced", nil, nil, true, false)
+	if l.warningCount != 1 || l.errorCount != 0 {
+		t.Errorf("logger counted %d warnings and %d errors, expected 1 and 0", l.warningCount, l.errorCount)
+	}
+	if strings.Contains(stdout.String(), "Counted") || !strings.Contains(stdout.String(), "DEBUG: ") {
+		t.Errorf("logger wrote %q, expected only the forced debug entry", stdout.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]Level{"error": LevelError, "WARNING": LevelWarn, "warn": LevelWarn, "trace": LevelTrace} {
+		if level, err := ParseLevel(name); err != nil || level != expected {
+			t.Errorf("ParseLevel(%q) = %v, %v, expected %v", name, level, err, expected)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("ParseLevel(\"verbose\") didn't fail")
+	}
+}
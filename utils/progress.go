@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	isatty "github.com/mattn/go-isatty"
+)
+
+// Progress modes
+const (
+	// ProgressAuto shows a progress bar when stderr is a terminal and nothing otherwise
+	ProgressAuto = "auto"
+	// ProgressBar always shows a progress bar on stderr
+	ProgressBar = "bar"
+	// ProgressJSON writes progress events to stderr as JSON, one object per line
+	ProgressJSON = "json"
+	// ProgressNone doesn't report progress
+	ProgressNone = "none"
+)
+
+// progressBarWidth is the number of characters of the bar itself
+const progressBarWidth = 30
+
+// progressInterval is how often the bar is redrawn and progress events are written
+const progressInterval = 100 * time.Millisecond
+
+// Progress tracks the progress of one phase of a run, e.g. the content scan
+type Progress struct {
+	phase    string
+	total    int
+	done     int
+	start    time.Time
+	reported time.Time
+}
+
+// progressEvent is a progress event of the JSON event stream
+type progressEvent struct {
+	Event     string `json:"event"`
+	Phase     string `json:"phase"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+	ElapsedMS int64  `json:"elapsed_ms"`
+	ETAMS     int64  `json:"eta_ms,omitempty"`
+}
+
+// progress is the state of progress reporting, the logger clears and redraws the active
+// bar around log lines so they don't end up in the middle of the bar
+var progress = struct {
+	sync.Mutex
+	mode   string
+	out    io.Writer
+	active *Progress
+	now    func() time.Time
+}{mode: ProgressNone, out: os.Stderr, now: time.Now}
+
+// ConfigureProgress sets how progress is reported, see the Progress modes
+func ConfigureProgress(mode string) error {
+	switch mode {
+	case ProgressAuto:
+		mode = ProgressNone
+		if isatty.IsTerminal(os.Stderr.Fd()) {
+			mode = ProgressBar
+		}
+	case ProgressBar, ProgressJSON, ProgressNone:
+	default:
+		return errors.New("unknown progress mode " + mode + ", use auto, bar, json or none")
+	}
+	progress.Lock()
+	defer progress.Unlock()
+	progress.mode = mode
+	return nil
+}
+
+// StartProgress starts reporting the progress of a phase with total steps
+func StartProgress(phase string, total int) *Progress {
+	progress.Lock()
+	defer progress.Unlock()
+	p := &Progress{phase: phase, total: total, start: progress.now()}
+	progress.active = p
+	p.report("start")
+	return p
+}
+
+// Increment marks a step of the phase as done
+func (p *Progress) Increment() {
+	progress.Lock()
+	defer progress.Unlock()
+	p.done++
+	if p.done == p.total || progress.now().Sub(p.reported) >= progressInterval {
+		p.report("progress")
+	}
+}
+
+// Finish ends the phase
+func (p *Progress) Finish() {
+	progress.Lock()
+	defer progress.Unlock()
+	p.report("finish")
+	if progress.mode == ProgressBar {
+		io.WriteString(progress.out, "\n")
+	}
+	if progress.active == p {
+		progress.active = nil
+	}
+}
+
+// report writes an event or draws the bar, progress has to be locked
+func (p *Progress) report(event string) {
+	now := progress.now()
+	p.reported = now
+	switch progress.mode {
+	case ProgressJSON:
+		elapsed := now.Sub(p.start)
+		b, _ := json.Marshal(progressEvent{
+			Event:     event,
+			Phase:     p.phase,
+			Done:      p.done,
+			Total:     p.total,
+			ElapsedMS: int64(elapsed / time.Millisecond),
+			ETAMS:     int64(p.eta(elapsed) / time.Millisecond),
+		})
+		io.WriteString(progress.out, string(b)+"\n")
+	case ProgressBar:
+		io.WriteString(progress.out, "\r\033[K"+p.bar(now.Sub(p.start)))
+	}
+}
+
+// bar renders the progress bar line, e.g. "content [######    ] 12/20 60% ETA 3s"
+func (p *Progress) bar(elapsed time.Duration) string {
+	if p.total <= 0 {
+		return p.phase + " " + strconv.Itoa(p.done)
+	}
+	filled := progressBarWidth * p.done / p.total
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+	line := p.phase + " [" + strings.Repeat("#", filled) + strings.Repeat(" ", progressBarWidth-filled) + "] " +
+		strconv.Itoa(p.done) + "/" + strconv.Itoa(p.total) + " " + strconv.Itoa(100*p.done/p.total) + "%"
+	if eta := p.eta(elapsed); eta > 0 {
+		line += " ETA " + eta.Round(time.Second).String()
+	}
+	return line
+}
+
+// eta estimates the time left from the average time per step so far, 0 when unknown
+func (p *Progress) eta(elapsed time.Duration) time.Duration {
+	if p.done <= 0 || p.done >= p.total {
+		return 0
+	}
+	return elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)
+}
+
+// clearProgress clears the active bar before a log line is written
+func clearProgress() {
+	progress.Lock()
+	defer progress.Unlock()
+	if progress.active != nil && progress.mode == ProgressBar {
+		io.WriteString(progress.out, "\r\033[K")
+	}
+}
+
+// redrawProgress draws the active bar again after a log line is written
+func redrawProgress() {
+	progress.Lock()
+	defer progress.Unlock()
+	if progress.active != nil && progress.mode == ProgressBar {
+		io.WriteString(progress.out, progress.active.bar(progress.now().Sub(progress.active.start)))
+	}
+}
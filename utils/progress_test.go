@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressBar(t *testing.T) {
+	p := &Progress{phase: "content", total: 20, done: 5}
+	expected := "content [#######                       ] 5/20 25% ETA 3s"
+	if bar := p.bar(time.Second); bar != expected {
+		t.Errorf("bar() = %q, expected %q", bar, expected)
+	}
+
+	p.done = 20
+	if eta := p.eta(time.Second); eta != 0 {
+		t.Errorf("eta() of a finished phase = %v, expected 0", eta)
+	}
+}
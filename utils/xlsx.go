@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Sheet is a worksheet of a workbook, the first row is written as a header row
+type Sheet struct {
+	Name string
+	// Rows hold strings and ints, ints are written as numbers
+	Rows [][]interface{}
+}
+
+// xlsxContentTypes is [Content_Types].xml, the sheet overrides are added per sheet
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+%s</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+// xlsxStyles has the default style and a bold style for header rows
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+
+// WriteXLSX writes the sheets as an Excel workbook. The header row of every sheet is
+// bold, frozen and has an auto filter so the sheets can be filtered right away.
+func WriteXLSX(path string, sheets []Sheet) error {
+	fo, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+
+	w := zip.NewWriter(fo)
+	var overrides, workbookSheets, workbookRels bytes.Buffer
+	for index, sheet := range sheets {
+		sheetID := strconv.Itoa(index + 1)
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%s.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", sheetID)
+		fmt.Fprintf(&workbookSheets, `<sheet name="%s" sheetId="%s" r:id="rId%s"/>`, xmlEscape(sheetName(sheet.Name)), sheetID, sheetID)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%s.xml"/>`, sheetID, sheetID)
+	}
+	stylesID := strconv.Itoa(len(sheets) + 1)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, stylesID)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, overrides.String())},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + workbookSheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + workbookRels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, file := range files {
+		if err := writeZipFile(w, file.name, []byte(file.content)); err != nil {
+			return err
+		}
+	}
+	for index, sheet := range sheets {
+		if err := writeZipFile(w, "xl/worksheets/sheet"+strconv.Itoa(index+1)+".xml", worksheetXML(sheet)); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// worksheetXML renders the rows of a sheet as a worksheet part
+func worksheetXML(sheet Sheet) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(sheet.Rows) > 1 {
+		buf.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	buf.WriteString(`<sheetData>`)
+	columns := 0
+	for rowIndex, row := range sheet.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+		rowID := strconv.Itoa(rowIndex + 1)
+		style := ""
+		if rowIndex == 0 {
+			style = ` s="1"`
+		}
+		buf.WriteString(`<row r="` + rowID + `">`)
+		for columnIndex, value := range row {
+			ref := ColumnName(columnIndex) + rowID
+			switch typed := value.(type) {
+			case int:
+				buf.WriteString(`<c r="` + ref + `"` + style + `><v>` + strconv.Itoa(typed) + `</v></c>`)
+			default:
+				buf.WriteString(`<c r="` + ref + `"` + style + ` t="inlineStr"><is><t xml:space="preserve">` + xmlEscape(fmt.Sprint(typed)) + `</t></is></c>`)
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData>`)
+	if len(sheet.Rows) > 0 && columns > 0 {
+		buf.WriteString(`<autoFilter ref="A1:` + ColumnName(columns-1) + strconv.Itoa(len(sheet.Rows)) + `"/>`)
+	}
+	buf.WriteString(`</worksheet>`)
+	return buf.Bytes()
+}
+
+// ColumnName returns the spreadsheet name of a zero based column index, e.g. 27 is AB
+func ColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// sheetName makes a name valid as an Excel sheet name
+func sheetName(name string) string {
+	var valid []rune
+	for _, r := range name {
+		switch r {
+		case '[', ']', ':', '*', '?', '/', '\\':
+			r = '_'
+		}
+		valid = append(valid, r)
+	}
+	if len(valid) > 31 {
+		valid = valid[:31]
+	}
+	return string(valid)
+}
+
+// xmlEscape escapes text for use in XML content and attributes
+func xmlEscape(value string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(value))
+	return buf.String()
+}
+
+// writeZipFile adds a file to a zip archive
+func writeZipFile(w *zip.Writer, name string, content []byte) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, bytes.NewReader(content))
+	return err
+}
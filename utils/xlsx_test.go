@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	for index, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if ColumnName(index) != name {
+			t.Errorf("ColumnName(%d) returned %s, expected %s", index, ColumnName(index), name)
+		}
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cartridgemapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.xlsx")
+	err = WriteXLSX(path, []Sheet{
+		{Name: "First", Rows: [][]interface{}{{"Name", "Count"}, {"a<b", 1}}},
+		{Name: "Second/Sheet", Rows: [][]interface{}{{"Name"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if len(r.File) != 7 {
+		t.Errorf("WriteXLSX() wrote %d parts, expected 7", len(r.File))
+	}
+}
@@ -2,68 +2,250 @@ package utils
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"io"
-	"log"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
+// Archive is a zip archive to un-compress and the folder in it to un-compress, empty for
+// the whole archive. The files of the folder end up at the top of the output directory.
+type Archive struct {
+	Path   string
+	Folder string
+}
+
+// MergeConflict is a file that more than one archive has with different content
+type MergeConflict struct {
+	// Path is the path of the file in the archive folders
+	Path string
+	// Sources are the archives that have the file in order, the file of the last one is kept
+	Sources []string
+}
+
 // Unzip will un-compress a zip archive,
 // moving all files and folders to an output directory
 func Unzip(src, dest string) ([]string, error) {
-
 	var filenames []string
+	err := unzipEach(src, "", dest, func(name string, f *zip.File, fpath string, hash string) {
+		filenames = append(filenames, fpath)
+	})
+	return filenames, err
+}
 
+// ZipFileNames returns the names of the files and folders in a zip archive
+func ZipFileNames(src string) ([]string, error) {
+	var names []string
 	r, err := zip.OpenReader(src)
 	if err != nil {
-		return filenames, err
+		return names, err
 	}
 	defer r.Close()
-
 	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	return names, nil
+}
 
+// ReadZipFiles returns the content of the files of a zip archive whose name matches, by name
+func ReadZipFiles(src string, match func(name string) bool) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return files, err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !match(f.Name) {
+			continue
+		}
 		rc, err := f.Open()
 		if err != nil {
-			return filenames, err
+			return files, err
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return files, err
+		}
+		files[f.Name] = content
+	}
+	return files, nil
+}
+
+// UnzipAll un-compresses the folders of several zip archives into one output directory,
+// overlaying them in order. A file that more than one archive has with the same content is
+// fine. When the content differs the file of the last archive is kept and the file is
+// returned as a conflict, conflicts are sorted by path.
+func UnzipAll(archives []Archive, dest string) ([]MergeConflict, error) {
+	type extracted struct {
+		sources  []string
+		hash     string
+		conflict bool
+	}
+	files := make(map[string]*extracted)
+	for _, archive := range archives {
+		src := archive.Path
+		err := unzipEach(src, archive.Folder, dest, func(name string, f *zip.File, fpath string, hash string) {
+			if f.FileInfo().IsDir() {
+				return
+			}
+			file, ok := files[name]
+			if !ok {
+				files[name] = &extracted{sources: []string{src}, hash: hash}
+				return
+			}
+			file.sources = append(file.sources, src)
+			if file.hash != hash {
+				file.conflict = true
+				file.hash = hash
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var conflicts []MergeConflict
+	for name, file := range files {
+		if file.conflict {
+			conflicts = append(conflicts, MergeConflict{Path: name, Sources: file.sources})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	return conflicts, nil
+}
+
+// unzipEach un-compresses a folder of a zip archive into an output directory, calling
+// extracted with every file and folder along with its name in the folder, its path and,
+// for files, the SHA-256 of its content
+func unzipEach(src string, folder string, dest string, extracted func(name string, f *zip.File, fpath string, hash string)) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	unzipProgress := StartProgress("unzip", len(r.File))
+	defer unzipProgress.Finish()
+	for _, f := range r.File {
+		unzipProgress.Increment()
+
+		name := path.Clean(f.Name)
+		if folder != "" {
+			if !strings.HasPrefix(name, folder+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(name, folder+"/")
 		}
-		defer rc.Close()
 
 		// Store filename/path for returning and using later on
-		fpath := filepath.Join(dest, f.Name)
-		filenames = append(filenames, fpath)
+		fpath := filepath.Join(dest, name)
+		if !withinDirectory(dest, fpath) {
+			return errors.New("zip entry " + f.Name + " of " + src + " is outside the output directory")
+		}
 
 		if f.FileInfo().IsDir() {
-
 			// Make Folder
 			os.MkdirAll(fpath, os.ModePerm)
+			extracted(name, f, fpath, "")
+			continue
+		}
 
-		} else {
+		hash, err := extractFile(f, fpath)
+		if err != nil {
+			return err
+		}
+		extracted(name, f, fpath, hash)
+	}
+	return nil
+}
 
-			// Make File
-			var fdir string
-			if lastIndex := strings.LastIndex(fpath, string(os.PathSeparator)); lastIndex > -1 {
-				fdir = fpath[:lastIndex]
-			}
+// withinDirectory checks if a path is the directory or inside it, so entries like ../x
+// can't be written outside of the output directory
+func withinDirectory(dir string, fpath string) bool {
+	rel, err := filepath.Rel(dir, fpath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
 
-			err = os.MkdirAll(fdir, os.ModePerm)
-			if err != nil {
-				log.Fatal(err)
-				return filenames, err
-			}
-			f, err := os.OpenFile(
-				fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-			if err != nil {
-				return filenames, err
-			}
-			defer f.Close()
+// extractFile writes a file of a zip archive, creating its folder, and returns the hex
+// SHA-256 of its content
+func extractFile(f *zip.File, fpath string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return "", err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
 
-			_, err = io.Copy(f, rc)
-			if err != nil {
-				return filenames, err
-			}
+	fo, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return "", err
+	}
+	defer fo.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(fo, hash), rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
 
+// RewriteZip writes a copy of a zip archive to dest, passing the name and content of every
+// file to rewrite first. rewrite returns the name and content to write, which are the ones
+// it was given for files it leaves as they are. Folders are renamed with rename.
+func RewriteZip(src string, dest string, rename func(name string) string, rewrite func(name string, content []byte) (string, []byte, error)) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	fo, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer fo.Close()
+	w := zip.NewWriter(fo)
+	for _, f := range r.File {
+		header := f.FileHeader
+		if f.FileInfo().IsDir() {
+			header.Name = rename(f.Name)
+			if _, err := w.CreateHeader(&header); err != nil {
+				return err
+			}
+			continue
 		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if header.Name, content, err = rewrite(f.Name, content); err != nil {
+			return err
+		}
+		fw, err := w.CreateHeader(&header)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
 	}
-	return filenames, nil
+	return fo.Close()
 }
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeZip writes a zip archive with the given files and contents
+func writeZip(t *testing.T, path string, files map[string]string) {
+	fo, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fo.Close()
+	w := zip.NewWriter(fo)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnzipAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	first := filepath.Join(dir, "templates.zip")
+	second := filepath.Join(dir, "site.zip")
+	writeZip(t, first, map[string]string{
+		"templates/Banner/template.xml": "<ContentTemplate/>",
+		"templates/Grid/template.xml":   "<ContentTemplate id=\"Grid\"/>",
+	})
+	writeZip(t, second, map[string]string{
+		"templates/Banner/template.xml": "<ContentTemplate/>",
+		"templates/Grid/template.xml":   "<ContentTemplate id=\"Grid2\"/>",
+		"pages/Default/content.xml":     "<ContentItem/>",
+	})
+
+	dest := filepath.Join(dir, "app")
+	conflicts, err := UnzipAll([]Archive{{Path: first}, {Path: second}}, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []MergeConflict{{Path: "templates/Grid/template.xml", Sources: []string{first, second}}}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("conflicts = %+v, expected %+v", conflicts, expected)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dest, "templates/Grid/template.xml"))
+	if err != nil || string(b) != "<ContentTemplate id=\"Grid2\"/>" {
+		t.Errorf("template.xml = %q, %v, expected the file of the last archive", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "pages/Default/content.xml")); err != nil {
+		t.Errorf("pages/Default/content.xml wasn't unzipped: %v", err)
+	}
+}
+
+func TestUnzipAllFolder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "apps.zip")
+	writeZip(t, src, map[string]string{
+		"Apps/Shop/templates/Banner/template.xml": "<ContentTemplate/>",
+		"Apps/Blog/templates/Post/template.xml":   "<ContentTemplate/>",
+	})
+
+	dest := filepath.Join(dir, "app")
+	if _, err := UnzipAll([]Archive{{Path: src, Folder: "Apps/Shop"}}, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "templates/Banner/template.xml")); err != nil {
+		t.Errorf("the folder wasn't unzipped to the top of the output directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "templates/Post")); !os.IsNotExist(err) {
+		t.Errorf("files outside the folder were unzipped: %v", err)
+	}
+}
+
+func TestUnzipRejectsEntriesOutsideDest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "evil.zip")
+	writeZip(t, src, map[string]string{"../escaped.txt": "outside"})
+
+	dest := filepath.Join(dir, "app")
+	if _, err := Unzip(src, dest); err == nil {
+		t.Error("Unzip() extracted an entry outside of the output directory")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); !os.IsNotExist(err) {
+		t.Errorf("the entry was written outside of the output directory: %v", err)
+	}
+	if !withinDirectory(".", "templates/Banner") || withinDirectory(".", "../templates") {
+		t.Error("withinDirectory() doesn't handle the current directory")
+	}
+}
+
+func TestRewriteZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "app.zip")
+	dest := filepath.Join(dir, "app-renamed.zip")
+	writeZip(t, src, map[string]string{
+		"templates/Old/":             "",
+		"templates/Old/template.xml": "<ContentTemplate id=\"Old\"/>",
+		"pages/home/content.xml":     "<ContentItem/>",
+	})
+
+	rename := func(name string) string {
+		if name == "templates/Old/" {
+			return "templates/New/"
+		}
+		return name
+	}
+	err = RewriteZip(src, dest, rename, func(name string, content []byte) (string, []byte, error) {
+		if name == "templates/Old/template.xml" {
+			return "templates/New/template.xml", []byte("<ContentTemplate id=\"New\"/>"), nil
+		}
+		return name, content, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out")
+	if _, err := Unzip(dest, out); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{
+		"templates/New/template.xml": "<ContentTemplate id=\"New\"/>",
+		"pages/home/content.xml":     "<ContentItem/>",
+	} {
+		if b, err := ioutil.ReadFile(filepath.Join(out, name)); err != nil || string(b) != expected {
+			t.Errorf("RewriteZip() wrote %s as %q, expected %q (%v)", name, b, expected, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "templates/Old")); !os.IsNotExist(err) {
+		t.Errorf("RewriteZip() kept the old folder")
+	}
+}